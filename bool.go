@@ -0,0 +1,94 @@
+package env
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// BoolVocabulary lists the words accepted as true and false by ParseBool,
+// and so by GetBool, GetOrBool, MustGetBool and bool struct fields. Unless
+// Exact is set, words match case-insensitively and surrounding spaces are
+// ignored.
+type BoolVocabulary struct {
+	True  []string
+	False []string
+	// Exact matches words exactly, without folding case or trimming spaces.
+	Exact bool
+}
+
+var (
+	// DefaultBoolVocabulary is the vocabulary used unless SetBoolVocabulary
+	// replaces it. It accepts everything strconv.ParseBool does, plus yes/no,
+	// y/n, on/off, enable/disable and enabled/disabled.
+	DefaultBoolVocabulary = BoolVocabulary{
+		True:  []string{"1", "t", "true", "y", "yes", "on", "enable", "enabled"},
+		False: []string{"0", "f", "false", "n", "no", "off", "disable", "disabled"},
+	}
+	// StrictBoolVocabulary accepts exactly what strconv.ParseBool accepts.
+	StrictBoolVocabulary = BoolVocabulary{
+		True:  []string{"1", "t", "T", "TRUE", "true", "True"},
+		False: []string{"0", "f", "F", "FALSE", "false", "False"},
+		Exact: true,
+	}
+)
+
+var (
+	boolVocabularyMu sync.RWMutex
+	boolVocabulary   = DefaultBoolVocabulary
+)
+
+// SetBoolVocabulary replaces the words ParseBool accepts and returns the
+// previous vocabulary, so that it can be put back. Pass StrictBoolVocabulary
+// to keep strconv.ParseBool semantics:
+//
+//	env.SetBoolVocabulary(env.StrictBoolVocabulary)
+func SetBoolVocabulary(v BoolVocabulary) BoolVocabulary {
+	boolVocabularyMu.Lock()
+	defer boolVocabularyMu.Unlock()
+
+	previous := boolVocabulary
+	boolVocabulary = v
+	return previous
+}
+
+// currentBoolVocabulary returns the vocabulary set by SetBoolVocabulary.
+func currentBoolVocabulary() BoolVocabulary {
+	boolVocabularyMu.RLock()
+	defer boolVocabularyMu.RUnlock()
+
+	return boolVocabulary
+}
+
+// ParseBoolWith parses s using the words of v. If a word is in both lists,
+// it is true. Errors are *strconv.NumError values, as from strconv.ParseBool.
+func ParseBoolWith(s string, v BoolVocabulary) (bool, error) {
+	word := s
+	if !v.Exact {
+		word = strings.TrimSpace(s)
+	}
+	if matchesWord(word, v.True, v.Exact) {
+		return true, nil
+	}
+	if matchesWord(word, v.False, v.Exact) {
+		return false, nil
+	}
+	return false, &strconv.NumError{Func: "ParseBool", Num: s, Err: strconv.ErrSyntax}
+}
+
+// BoolParser returns a Parser for booleans using the words of v, for use with
+// GetParsed and the other generic functions regardless of SetBoolVocabulary.
+func BoolParser(v BoolVocabulary) Parser[bool] {
+	return func(s string) (bool, error) {
+		return ParseBoolWith(s, v)
+	}
+}
+
+func matchesWord(word string, words []string, exact bool) bool {
+	for _, w := range words {
+		if word == w || (!exact && strings.EqualFold(word, w)) {
+			return true
+		}
+	}
+	return false
+}
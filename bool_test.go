@@ -0,0 +1,78 @@
+package env
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBoolVocabulary(t *testing.T) {
+	for _, s := range []string{"1", "t", "T", "true", "TRUE", "True", "y", "Y", "yes", "YES", "on", "On", "enable", "enabled", "Enabled", " yes "} {
+		b, err := ParseBool(s)
+		assert.NoError(t, err, s)
+		assert.True(t, b, s)
+	}
+	for _, s := range []string{"0", "f", "F", "false", "FALSE", "n", "N", "no", "No", "off", "OFF", "disable", "disabled"} {
+		b, err := ParseBool(s)
+		assert.NoError(t, err, s)
+		assert.False(t, b, s)
+	}
+	for _, s := range []string{"", "2", "yess", "nope", "truthy"} {
+		_, err := ParseBool(s)
+		assert.ErrorIs(t, err, strconv.ErrSyntax, s)
+	}
+}
+
+func TestSetBoolVocabulary(t *testing.T) {
+	previous := SetBoolVocabulary(BoolVocabulary{True: []string{"ja"}, False: []string{"nein"}})
+	t.Cleanup(func() { SetBoolVocabulary(previous) })
+	SetForTest(t, "BOOL_VOCAB", "Ja")
+	SetForTest(t, "BOOL_VOCAB_YES", "yes")
+
+	assert.True(t, MustGetBool("BOOL_VOCAB"))
+	_, err := GetBool("BOOL_VOCAB_YES")
+	assert.Error(t, err)
+
+	bools, err := parseBools([]string{"ja", "nein"})
+	assert.NoError(t, err)
+	assert.Equal(t, []bool{true, false}, bools)
+}
+
+func TestStrictBoolVocabulary(t *testing.T) {
+	previous := SetBoolVocabulary(StrictBoolVocabulary)
+	t.Cleanup(func() { SetBoolVocabulary(previous) })
+
+	for _, s := range []string{"1", "t", "T", "TRUE", "true", "True", "0", "f", "F", "FALSE", "false", "False", "yes", "on", "tRUE", " true"} {
+		want, wantErr := strconv.ParseBool(s)
+		got, err := ParseBool(s)
+		assert.Equal(t, want, got, s)
+		assert.Equal(t, wantErr, err, s)
+	}
+}
+
+func TestBoolStructFields(t *testing.T) {
+	type config struct {
+		Debug    bool   `env:"BOOL_FIELD_DEBUG"`
+		Features []bool `env:"BOOL_FIELD_FEATURES"`
+	}
+	SetForTest(t, "BOOL_FIELD_DEBUG", "on")
+	SetForTest(t, "BOOL_FIELD_FEATURES", "yes,no,enabled")
+
+	var cfg config
+	assert.NoError(t, Parse(&cfg))
+	assert.True(t, cfg.Debug)
+	assert.Equal(t, []bool{true, false, true}, cfg.Features)
+}
+
+func TestBoolParser(t *testing.T) {
+	t.Parallel()
+	e := NewEnvironment(MapSource(map[string]string{"BOOL_PARSER": "yes"}))
+
+	b, err := GetParsedFrom(e, "BOOL_PARSER", BoolParser(DefaultBoolVocabulary))
+	assert.NoError(t, err)
+	assert.True(t, b)
+
+	_, err = GetParsedFrom(e, "BOOL_PARSER", BoolParser(StrictBoolVocabulary))
+	assert.Error(t, err)
+}
@@ -0,0 +1,171 @@
+package env
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ByteSize is a number of bytes, such as a buffer or cache limit. It parses
+// and formats human-readable sizes such as "512", "64KB" and "1.5GiB", and can
+// be used directly as a struct field or slice element:
+//
+//	type Config struct {
+//		CacheSize ByteSize   `env:"CACHE_SIZE" envDefault:"64MiB"`
+//		Buffers   []ByteSize `env:"BUFFERS"`
+//	}
+type ByteSize uint64
+
+// Decimal (SI) and binary (IEC) byte size units.
+const (
+	Byte ByteSize = 1
+
+	KB ByteSize = 1000 * Byte
+	MB ByteSize = 1000 * KB
+	GB ByteSize = 1000 * MB
+	TB ByteSize = 1000 * GB
+	PB ByteSize = 1000 * TB
+	EB ByteSize = 1000 * PB
+
+	KiB ByteSize = 1024 * Byte
+	MiB ByteSize = 1024 * KiB
+	GiB ByteSize = 1024 * MiB
+	TiB ByteSize = 1024 * GiB
+	PiB ByteSize = 1024 * TiB
+	EiB ByteSize = 1024 * PiB
+)
+
+// byteSizeUnits lists the units from largest to smallest.
+var byteSizeUnits = []struct {
+	name string
+	size ByteSize
+}{
+	{"EiB", EiB}, {"EB", EB}, {"PiB", PiB}, {"PB", PB}, {"TiB", TiB}, {"TB", TB},
+	{"GiB", GiB}, {"GB", GB}, {"MiB", MiB}, {"MB", MB}, {"KiB", KiB}, {"KB", KB},
+}
+
+// byteSizeUnitNames maps the lower-cased unit names ParseByteSize accepts to
+// their sizes. Single letters are decimal, as for NumberFormat.SI.
+var byteSizeUnitNames = map[string]ByteSize{
+	"": Byte, "b": Byte,
+	"k": KB, "kb": KB, "m": MB, "mb": MB, "g": GB, "gb": GB,
+	"t": TB, "tb": TB, "p": PB, "pb": PB, "e": EB, "eb": EB,
+	"ki": KiB, "kib": KiB, "mi": MiB, "mib": MiB, "gi": GiB, "gib": GiB,
+	"ti": TiB, "tib": TiB, "pi": PiB, "pib": PiB, "ei": EiB, "eib": EiB,
+}
+
+var byteSizeRegex = regexp.MustCompile(`^([0-9]+(?:\.[0-9]*)?|\.[0-9]+)\s*([a-zA-Z]*)$`)
+
+// ParseByteSize parses a size such as "512", "64KB", "1.5GiB" or "10 MB".
+// Units are case-insensitive; KB, MB, GB, TB, PB and EB (or k, M, G, T, P and
+// E) are powers of 1000, and KiB, MiB, GiB, TiB, PiB and EiB (or Ki, Mi, ...)
+// are powers of 1024. A fraction is allowed as long as the result is a whole
+// number of bytes. Sizes that don't fit in a uint64 are errors wrapping
+// strconv.ErrRange.
+func ParseByteSize(s string) (ByteSize, error) {
+	m := byteSizeRegex.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return 0, fmt.Errorf("invalid byte size %q: %w", s, strconv.ErrSyntax)
+	}
+	unit, ok := byteSizeUnitNames[strings.ToLower(m[2])]
+	if !ok {
+		return 0, fmt.Errorf("invalid byte size %q: unknown unit %q", s, m[2])
+	}
+
+	number, ok := new(big.Rat).SetString(m[1])
+	if !ok {
+		return 0, fmt.Errorf("invalid byte size %q: %w", s, strconv.ErrSyntax)
+	}
+	number.Mul(number, new(big.Rat).SetUint64(uint64(unit)))
+	if !number.IsInt() {
+		return 0, fmt.Errorf("invalid byte size %q: not a whole number of bytes", s)
+	}
+	if !number.Num().IsUint64() {
+		return 0, fmt.Errorf("invalid byte size %q: %w", s, strconv.ErrRange)
+	}
+	return ByteSize(number.Num().Uint64()), nil
+}
+
+// String formats b in the unit that gives the shortest result with at most
+// three decimal places, such as "1.5GiB", "64KB" or "1536B", preferring
+// larger units on a tie. Plain bytes are one of the candidates, so sizes such
+// as 512 are formatted in bytes. ParseByteSize reads the result back exactly.
+func (b ByteSize) String() string {
+	best := strconv.FormatUint(uint64(b), DecimalBase) + "B"
+	value := new(big.Rat).SetUint64(uint64(b))
+	for i := len(byteSizeUnits) - 1; i >= 0; i-- {
+		u := byteSizeUnits[i]
+		if b < u.size {
+			break
+		}
+		scaled := new(big.Rat).Quo(value, new(big.Rat).SetUint64(uint64(u.size)))
+		if !new(big.Rat).Mul(scaled, big.NewRat(1000, 1)).IsInt() {
+			continue
+		}
+		if s := trimFraction(scaled.FloatString(3)) + u.name; len(s) <= len(best) {
+			best = s
+		}
+	}
+	return best
+}
+
+// trimFraction removes trailing zeros, and a trailing point, from a decimal.
+func trimFraction(s string) string {
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s
+}
+
+// MarshalText implements encoding.TextMarshaler using String.
+func (b ByteSize) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler using ParseByteSize.
+func (b *ByteSize) UnmarshalText(text []byte) error {
+	size, err := ParseByteSize(string(text))
+	if err != nil {
+		return err
+	}
+	*b = size
+	return nil
+}
+
+// GetByteSize retrieves an environment variable and parses it as a ByteSize.
+// It accepts sizes like "512", "64KB" and "1.5GiB"; see ParseByteSize.
+// Returns the parsed size and any parsing error.
+func GetByteSize(key string) (ByteSize, error) {
+	return defaultEnvironment.GetByteSize(key)
+}
+
+// GetOrByteSize retrieves an environment variable and parses it as a ByteSize.
+// If the variable is not set or parsing fails, it returns the default value.
+func GetOrByteSize(key string, defaultValue ByteSize) ByteSize {
+	return defaultEnvironment.GetOrByteSize(key, defaultValue)
+}
+
+// MustGetByteSize retrieves an environment variable and parses it as a ByteSize.
+// If the variable is not set or parsing fails, it panics.
+func MustGetByteSize(key string) ByteSize {
+	return defaultEnvironment.MustGetByteSize(key)
+}
+
+// GetByteSize parses the value of key as a ByteSize.
+func (e *Environment) GetByteSize(key string) (ByteSize, error) {
+	return GetParsedFrom(e, key, ParseByteSize)
+}
+
+// GetOrByteSize parses the value of key as a ByteSize, returning defaultValue
+// if it is not set or invalid.
+func (e *Environment) GetOrByteSize(key string, defaultValue ByteSize) ByteSize {
+	return GetOrParsedFrom(e, key, defaultValue, ParseByteSize)
+}
+
+// MustGetByteSize parses the value of key as a ByteSize, panicking if it is
+// not set or invalid.
+func (e *Environment) MustGetByteSize(key string) ByteSize {
+	return MustGetParsedFrom(e, key, ParseByteSize, "env.ByteSize")
+}
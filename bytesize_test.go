@@ -0,0 +1,143 @@
+package env
+
+import (
+	"encoding"
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	_ encoding.TextMarshaler   = ByteSize(0)
+	_ encoding.TextUnmarshaler = (*ByteSize)(nil)
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want ByteSize
+	}{
+		{"0", 0},
+		{"512", 512},
+		{"512B", 512},
+		{"64KB", 64000},
+		{"64kb", 64000},
+		{"64k", 64000},
+		{"64KiB", 64 * 1024},
+		{"64Ki", 64 * 1024},
+		{"10 MB", 10 * MB},
+		{"1.5GiB", 3 * GiB / 2},
+		{"1.5GB", 1500 * MB},
+		{".5KiB", 512},
+		{"2.KB", 2000},
+		{" 3TiB ", 3 * TiB},
+		{"1PB", PB},
+		{"15EiB", 15 * EiB},
+		{"18446744073709551615", ByteSize(^uint64(0))},
+	}
+	for _, tt := range tests {
+		got, err := ParseByteSize(tt.in)
+		if assert.NoError(t, err, tt.in) {
+			assert.Equal(t, tt.want, got, tt.in)
+		}
+	}
+}
+
+func TestParseByteSizeErrors(t *testing.T) {
+	for _, in := range []string{"", "KB", "-1KB", "1.2.3MB", "1e3", "0x10"} {
+		_, err := ParseByteSize(in)
+		assert.ErrorIs(t, err, strconv.ErrSyntax, in)
+	}
+
+	_, err := ParseByteSize("16EiB")
+	assert.ErrorIs(t, err, strconv.ErrRange)
+	_, err = ParseByteSize("18446744073709551616")
+	assert.ErrorIs(t, err, strconv.ErrRange)
+
+	_, err = ParseByteSize("10 XB")
+	assert.EqualError(t, err, `invalid byte size "10 XB": unknown unit "XB"`)
+	_, err = ParseByteSize("1.5B")
+	assert.EqualError(t, err, `invalid byte size "1.5B": not a whole number of bytes`)
+}
+
+func TestByteSizeString(t *testing.T) {
+	tests := []struct {
+		in   ByteSize
+		want string
+	}{
+		{0, "0B"},
+		{512, "512B"},
+		{1000, "1KB"},
+		{1024, "1KiB"},
+		{1536, "1536B"},
+		{3 * MiB / 2, "1.5MiB"},
+		{64 * KB, "64KB"},
+		{3 * GiB / 2, "1.5GiB"},
+		{1234567, "1234567B"},
+		{1500 * KB, "1.5MB"},
+		{EiB + 1, "1152921504606846977B"},
+		{ByteSize(math.MaxUint64), "18446744073709551615B"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tt.in.String())
+
+		text, err := tt.in.MarshalText()
+		assert.NoError(t, err)
+		var back ByteSize
+		assert.NoError(t, back.UnmarshalText(text))
+		assert.Equal(t, tt.in, back, "round trip of %s", text)
+	}
+}
+
+func TestByteSizeFuncs(t *testing.T) {
+	SetForTest(t, "BYTE_SIZE", "64MiB")
+	SetForTest(t, "BAD_BYTE_SIZE", "lots")
+	UnsetForTest(t, "ENV_NO_EXISTS")
+
+	size, err := GetByteSize("BYTE_SIZE")
+	assert.NoError(t, err)
+	assert.Equal(t, 64*MiB, size)
+	assert.Equal(t, 64*MiB, MustGetByteSize("BYTE_SIZE"))
+	assert.Equal(t, 64*MiB, GetOrByteSize("BYTE_SIZE", KiB))
+
+	_, err = GetByteSize("ENV_NO_EXISTS")
+	assert.ErrorIs(t, err, ErrNotSet)
+	assert.Equal(t, KiB, GetOrByteSize("ENV_NO_EXISTS", KiB))
+	assert.Panics(t, func() { MustGetByteSize("ENV_NO_EXISTS") })
+
+	_, err = GetByteSize("BAD_BYTE_SIZE")
+	assert.Error(t, err)
+	assert.Equal(t, KiB, GetOrByteSize("BAD_BYTE_SIZE", KiB))
+	assert.PanicsWithValue(t, `environment variable "BAD_BYTE_SIZE" could not be converted to env.ByteSize`, func() {
+		MustGetByteSize("BAD_BYTE_SIZE")
+	})
+}
+
+func TestByteSizeStructFields(t *testing.T) {
+	type config struct {
+		Cache   ByteSize   `env:"BYTE_SIZE_CACHE"`
+		Default ByteSize   `env:"BYTE_SIZE_DEFAULT" envDefault:"1.5GiB"`
+		Limit   *ByteSize  `env:"BYTE_SIZE_LIMIT"`
+		Buffers []ByteSize `env:"BYTE_SIZE_BUFFERS"`
+	}
+	SetForTest(t, "BYTE_SIZE_CACHE", "64KB")
+	UnsetForTest(t, "BYTE_SIZE_DEFAULT")
+	SetForTest(t, "BYTE_SIZE_LIMIT", "2GiB")
+	SetForTest(t, "BYTE_SIZE_BUFFERS", "4KiB,1MB,512")
+
+	var cfg config
+	assert.NoError(t, Parse(&cfg))
+	assert.Equal(t, 64*KB, cfg.Cache)
+	assert.Equal(t, 3*GiB/2, cfg.Default)
+	if assert.NotNil(t, cfg.Limit) {
+		assert.Equal(t, 2*GiB, *cfg.Limit)
+	}
+	assert.Equal(t, []ByteSize{4 * KiB, MB, 512}, cfg.Buffers)
+
+	SetForTest(t, "BYTE_SIZE_BUFFERS", "4KiB,huge")
+	cfg = config{}
+	err := Parse(&cfg)
+	assert.ErrorContains(t, err, `element 1 ("huge")`)
+}
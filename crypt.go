@@ -0,0 +1,248 @@
+package env
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	// EncryptedPrefix marks a value in a .env file as encrypted. The rest of
+	// the value is the base64 encoding of an AES-GCM nonce followed by the
+	// sealed plaintext.
+	EncryptedPrefix = "enc:"
+
+	// EncryptionKeyVar is the environment variable holding the key used to
+	// decrypt encrypted values. LoadEncrypted reads it from the process
+	// environment; DecryptionKeys and ParseOptions.EncryptionKeys can supply
+	// it from anywhere else.
+	EncryptionKeyVar = "ENV_ENCRYPTION_KEY"
+)
+
+// ErrMissingKey is returned by LoadEncrypted when no encryption key is available.
+var ErrMissingKey = errors.New("encryption key not set")
+
+// DecryptionKeys opts the file based functions (Load, Overload, Read and
+// their variants) in to decrypting encrypted values transparently while
+// parsing .env files. If not nil, EncryptionKeyVar is looked up through it and
+// values with the "enc:" prefix are decrypted with that key; values are left
+// as written if it has no key. It is nil by default, so files are read as
+// written whatever the environment holds:
+//
+//	env.DecryptionKeys = env.LookupFunc(os.LookupEnv)
+//	err := env.Load(".env", "secrets.env")
+//
+// ParseIO is not affected; use ParseOptions.EncryptionKeys instead.
+var DecryptionKeys Lookuper
+
+// GenerateKey returns a new random 256-bit key, base64 encoded, suitable for
+// EncryptValue and for storing in EncryptionKeyVar.
+func GenerateKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// EncryptValue encrypts value with AES-GCM and returns it with the "enc:" prefix,
+// ready to be written to a .env file. The key is the base64 encoding of a 16,
+// 24 or 32 byte AES key, as returned by GenerateKey.
+//
+// Each call uses a fresh random nonce, so encrypting the same value twice
+// gives different results.
+func EncryptValue(value, key string) (string, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(value), nil)
+	return EncryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptValue decrypts a value produced by EncryptValue. Values without the
+// "enc:" prefix are returned unchanged, so it can be applied to every value of
+// a partially encrypted file.
+func DecryptValue(value, key string) (string, error) {
+	if !strings.HasPrefix(value, EncryptedPrefix) {
+		return value, nil
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, EncryptedPrefix))
+	if err != nil {
+		return "", fmt.Errorf("malformed encrypted value: %w", err)
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("malformed encrypted value: too short")
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("decrypting value: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// Encrypt returns a copy of envMap with every value encrypted by EncryptValue.
+// Values that are already encrypted are kept as they are.
+//
+// Write the result with Write or Marshal to produce an encrypted .env file:
+//
+//	encrypted, err := env.Encrypt(secrets, key)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	err = env.Write(encrypted, "secrets.env")
+func Encrypt(envMap map[string]string, key string) (map[string]string, error) {
+	result := make(map[string]string, len(envMap))
+	for k, v := range envMap {
+		if !strings.HasPrefix(v, EncryptedPrefix) {
+			var err error
+			if v, err = EncryptValue(v, key); err != nil {
+				return nil, fmt.Errorf("%s: %w", k, err)
+			}
+		}
+		result[k] = v
+	}
+	return result, nil
+}
+
+// Decrypt returns a copy of envMap with every encrypted value decrypted.
+// Values without the "enc:" prefix are copied unchanged.
+func Decrypt(envMap map[string]string, key string) (map[string]string, error) {
+	result := make(map[string]string, len(envMap))
+	for k, v := range envMap {
+		plaintext, err := DecryptValue(v, key)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", k, err)
+		}
+		result[k] = plaintext
+	}
+	return result, nil
+}
+
+// RotateKey returns a copy of envMap with every encrypted value decrypted with
+// oldKey and encrypted again with newKey. Plain values are copied unchanged.
+func RotateKey(envMap map[string]string, oldKey, newKey string) (map[string]string, error) {
+	result := make(map[string]string, len(envMap))
+	for k, v := range envMap {
+		rotated, err := rotateValue(v, oldKey, newKey)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", k, err)
+		}
+		result[k] = rotated
+	}
+	return result, nil
+}
+
+// RotateFile re-encrypts every encrypted value in the named .env file with
+// newKey. Only the lines holding encrypted values are rewritten; comments,
+// ordering and plain values are preserved (see Document). The file is
+// replaced atomically and keeps its permissions.
+func RotateFile(filename, oldKey, newKey string) error {
+	doc, err := ReadDocument(filename)
+	if err != nil {
+		return err
+	}
+
+	// Every definition is rotated, not just the one Get returns, so that no
+	// duplicate is left under the old key.
+	err = doc.rewriteValues(func(key, value string) (string, error) {
+		rotated, err := rotateValue(value, oldKey, newKey)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", key, err)
+		}
+		return rotated, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return doc.WriteFile(filename, WriteOptions{Fsync: true})
+}
+
+// LoadEncrypted is like Load, but decrypts encrypted values with the key set
+// in EncryptionKeyVar. It fails if the key is not set or if any encrypted
+// value cannot be decrypted, so values are never applied still encrypted.
+//
+// Like Load, it will NOT override environment variables that are already set.
+func LoadEncrypted(filenames ...string) error {
+	return LoadEncryptedWith(LookupFunc(os.LookupEnv), filenames...)
+}
+
+// LoadEncryptedWith is like LoadEncrypted, but looks up EncryptionKeyVar
+// through keys instead of the process environment, for example to take the
+// key from a secrets manager.
+func LoadEncryptedWith(keys Lookuper, filenames ...string) error {
+	if _, ok := keys.Lookup(EncryptionKeyVar); !ok {
+		return fmt.Errorf("%w: %s", ErrMissingKey, EncryptionKeyVar)
+	}
+
+	read := func(filename string) (map[string]string, error) {
+		file, err := os.Open(filename)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		return newDotenvParser(osIncludes{}).withOptions(ParseOptions{EncryptionKeys: keys}).parse(file, filename)
+	}
+	return loadFiles(read, filenames, false)
+}
+
+// decryptParsedValue decrypts a value read from a .env file when keys holds
+// the key. Without a key the value is returned as written.
+func decryptParsedValue(name, value string, keys Lookuper) (string, error) {
+	if keys == nil || !strings.HasPrefix(value, EncryptedPrefix) {
+		return value, nil
+	}
+	key, ok := keys.Lookup(EncryptionKeyVar)
+	if !ok {
+		return value, nil
+	}
+
+	plaintext, err := DecryptValue(value, key)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	return plaintext, nil
+}
+
+func rotateValue(value, oldKey, newKey string) (string, error) {
+	if !strings.HasPrefix(value, EncryptedPrefix) {
+		return value, nil
+	}
+	plaintext, err := DecryptValue(value, oldKey)
+	if err != nil {
+		return "", err
+	}
+	return EncryptValue(plaintext, newKey)
+}
+
+func newAEAD(key string) (cipher.AEAD, error) {
+	raw, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key: %w", err)
+	}
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
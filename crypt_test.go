@@ -0,0 +1,251 @@
+package env
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestKey(t *testing.T) string {
+	t.Helper()
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	return key
+}
+
+func TestEncryptValueRoundtrip(t *testing.T) {
+	key := newTestKey(t)
+
+	for _, value := range []string{"", "secret", "multi\nline $VAR \"quoted\"", "ünïcode"} {
+		encrypted, err := EncryptValue(value, key)
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(encrypted, EncryptedPrefix))
+
+		decrypted, err := DecryptValue(encrypted, key)
+		assert.NoError(t, err)
+		assert.Equal(t, value, decrypted)
+	}
+
+	a, _ := EncryptValue("same", key)
+	b, _ := EncryptValue("same", key)
+	assert.NotEqual(t, a, b, "each encryption should use a fresh nonce")
+}
+
+func TestDecryptValueErrors(t *testing.T) {
+	key := newTestKey(t)
+	encrypted, err := EncryptValue("secret", key)
+	assert.NoError(t, err)
+
+	plain, err := DecryptValue("not encrypted", key)
+	assert.NoError(t, err)
+	assert.Equal(t, "not encrypted", plain)
+
+	_, err = DecryptValue(encrypted, newTestKey(t))
+	assert.Error(t, err, "wrong key")
+	_, err = DecryptValue("enc:!!!", key)
+	assert.Error(t, err, "bad base64")
+	_, err = DecryptValue("enc:AAAA", key)
+	assert.Error(t, err, "too short")
+	_, err = DecryptValue(encrypted, "c2hvcnQ=")
+	assert.Error(t, err, "bad key length")
+	_, err = EncryptValue("secret", "not base64!")
+	assert.Error(t, err)
+}
+
+func TestEncryptDecryptMap(t *testing.T) {
+	key := newTestKey(t)
+	alreadyEncrypted, _ := EncryptValue("kept", key)
+
+	encrypted, err := Encrypt(map[string]string{"A": "1", "B": alreadyEncrypted}, key)
+	assert.NoError(t, err)
+	assert.Equal(t, alreadyEncrypted, encrypted["B"])
+	assert.True(t, strings.HasPrefix(encrypted["A"], EncryptedPrefix))
+
+	decrypted, err := Decrypt(encrypted, key)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"A": "1", "B": "kept"}, decrypted)
+
+	_, err = Decrypt(encrypted, newTestKey(t))
+	assert.Error(t, err)
+}
+
+func TestParseIODecryptsWithOption(t *testing.T) {
+	key := newTestKey(t)
+	encrypted, _ := EncryptValue("s3cret", key)
+	content := "PLAIN=visible\nSECRET=" + encrypted + "\n"
+	keys := func(key string) Lookuper {
+		return LookupFunc(func(name string) (string, bool) {
+			return key, name == EncryptionKeyVar
+		})
+	}
+
+	envMap, err := ParseIOWithOptions(strings.NewReader(content), ParseOptions{EncryptionKeys: keys(key)})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"PLAIN": "visible", "SECRET": "s3cret"}, envMap)
+
+	_, err = ParseIOWithOptions(strings.NewReader(content), ParseOptions{EncryptionKeys: keys(newTestKey(t))})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "SECRET")
+
+	envMap, err = ParseIOWithOptions(strings.NewReader(content), ParseOptions{EncryptionKeys: MapSource(nil)})
+	assert.NoError(t, err)
+	assert.Equal(t, encrypted, envMap["SECRET"], "without a key values are left encrypted")
+}
+
+func TestParseIOIgnoresEncryptionKeyVar(t *testing.T) {
+	key := newTestKey(t)
+	encrypted, _ := EncryptValue("s3cret", key)
+	content := "SECRET=" + encrypted + "\nPLAIN=enc:not base64\n"
+	SetForTest(t, EncryptionKeyVar, key)
+
+	envMap, err := ParseIO(strings.NewReader(content))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"SECRET": encrypted, "PLAIN": "enc:not base64"}, envMap)
+
+	filename := filepath.Join(t.TempDir(), "secrets.env")
+	assert.NoError(t, os.WriteFile(filename, []byte(content), 0600))
+	envMap, err = Read(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, encrypted, envMap["SECRET"])
+
+	marshalled, err := MarshalWithOptions(envMap, MarshalOptions{})
+	assert.NoError(t, err)
+	roundTrip, err := Unmarshal(marshalled)
+	assert.NoError(t, err)
+	assert.Equal(t, envMap, roundTrip)
+}
+
+func TestDecryptionKeys(t *testing.T) {
+	key := newTestKey(t)
+	encrypted, _ := EncryptValue("s3cret", key)
+	filename := filepath.Join(t.TempDir(), "secrets.env")
+	assert.NoError(t, os.WriteFile(filename, []byte("CRYPT_OPT_IN="+encrypted+"\nCRYPT_OPT_IN_PLAIN=p\n"), 0600))
+	UnsetForTest(t, "CRYPT_OPT_IN")
+	UnsetForTest(t, "CRYPT_OPT_IN_PLAIN")
+	t.Cleanup(func() { DecryptionKeys = nil })
+
+	keys := LookupFunc(func(name string) (string, bool) {
+		return key, name == EncryptionKeyVar
+	})
+	DecryptionKeys = keys
+	envMap, err := Read(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"CRYPT_OPT_IN": "s3cret", "CRYPT_OPT_IN_PLAIN": "p"}, envMap)
+
+	assert.NoError(t, Load(filename))
+	assert.Equal(t, "s3cret", os.Getenv("CRYPT_OPT_IN"))
+
+	envMap, err = ParseIO(strings.NewReader("CRYPT_OPT_IN=" + encrypted))
+	assert.NoError(t, err)
+	assert.Equal(t, encrypted, envMap["CRYPT_OPT_IN"], "ParseIO is not affected")
+
+	DecryptionKeys = LookupFunc(func(string) (string, bool) { return "", false })
+	envMap, err = Read(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, encrypted, envMap["CRYPT_OPT_IN"], "no key leaves values as written")
+
+	DecryptionKeys = LookupFunc(func(name string) (string, bool) {
+		return newTestKey(t), name == EncryptionKeyVar
+	})
+	_, err = Read(filename)
+	assert.ErrorContains(t, err, "CRYPT_OPT_IN")
+
+	DecryptionKeys = nil
+	envMap, err = Read(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, encrypted, envMap["CRYPT_OPT_IN"])
+}
+
+func TestLoadEncrypted(t *testing.T) {
+	key := newTestKey(t)
+	encrypted, err := Encrypt(map[string]string{"CRYPT_TEST_SECRET": "hunter2"}, key)
+	assert.NoError(t, err)
+
+	filename := filepath.Join(t.TempDir(), "secrets.env")
+	assert.NoError(t, Write(encrypted, filename))
+	UnsetForTest(t, "CRYPT_TEST_SECRET")
+
+	UnsetForTest(t, EncryptionKeyVar)
+	err = LoadEncrypted(filename)
+	assert.True(t, errors.Is(err, ErrMissingKey))
+
+	keys := LookupFunc(func(name string) (string, bool) {
+		return key, name == EncryptionKeyVar
+	})
+	assert.NoError(t, LoadEncryptedWith(keys, filename))
+	assert.Equal(t, "hunter2", os.Getenv("CRYPT_TEST_SECRET"))
+}
+
+func TestRotateKey(t *testing.T) {
+	oldKey, newKey := newTestKey(t), newTestKey(t)
+	encrypted, _ := Encrypt(map[string]string{"A": "1"}, oldKey)
+	encrypted["PLAIN"] = "p"
+
+	rotated, err := RotateKey(encrypted, oldKey, newKey)
+	assert.NoError(t, err)
+	assert.Equal(t, "p", rotated["PLAIN"])
+
+	decrypted, err := Decrypt(rotated, newKey)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"A": "1", "PLAIN": "p"}, decrypted)
+
+	_, err = RotateKey(encrypted, newKey, oldKey)
+	assert.Error(t, err)
+}
+
+func TestRotateFile(t *testing.T) {
+	oldKey, newKey := newTestKey(t), newTestKey(t)
+	encrypted, _ := EncryptValue("s3cret", oldKey)
+
+	filename := filepath.Join(t.TempDir(), "secrets.env")
+	content := "# database\nDB_USER=admin\nDB_PASSWORD=" + encrypted + " # rotated yearly\n"
+	assert.NoError(t, os.WriteFile(filename, []byte(content), 0640))
+
+	assert.NoError(t, RotateFile(filename, oldKey, newKey))
+
+	data, err := os.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(data), "# database\nDB_USER=admin\nDB_PASSWORD=enc:"))
+	assert.True(t, strings.HasSuffix(string(data), " # rotated yearly\n"))
+	assert.NotContains(t, string(data), encrypted)
+
+	info, err := os.Stat(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+
+	envMap, err := Read(filename)
+	assert.NoError(t, err)
+	envMap, err = Decrypt(envMap, newKey)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"DB_USER": "admin", "DB_PASSWORD": "s3cret"}, envMap)
+}
+
+func TestRotateFileDuplicates(t *testing.T) {
+	oldKey, newKey := newTestKey(t), newTestKey(t)
+	first, _ := EncryptValue("first", oldKey)
+	last, _ := EncryptValue("last", oldKey)
+
+	filename := filepath.Join(t.TempDir(), "secrets.env")
+	content := "TOKEN=" + first + "\nPLAIN=p\nTOKEN=" + last + "\n"
+	assert.NoError(t, os.WriteFile(filename, []byte(content), 0600))
+
+	assert.NoError(t, RotateFile(filename, oldKey, newKey))
+	doc, err := ReadDocument(filename)
+	assert.NoError(t, err)
+	var values []string
+	assert.NoError(t, doc.rewriteValues(func(key, value string) (string, error) {
+		if key == "TOKEN" {
+			decrypted, err := DecryptValue(value, newKey)
+			assert.NoError(t, err)
+			values = append(values, decrypted)
+		}
+		return value, nil
+	}))
+	assert.Equal(t, []string{"first", "last"}, values)
+}
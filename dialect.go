@@ -0,0 +1,448 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Dialect selects the rules used to read a .env file. Tools disagree on
+// quoting, escaping, comments and variable expansion, so a file written for
+// one of them is best read with the matching dialect.
+type Dialect int
+
+const (
+	// DialectGodotenv is the default and the behaviour of ParseIO: KEY=value
+	// or KEY: value, "#" comments outside quotes, \n and \r escapes in double
+	// quotes, and $VAR/${VAR} expansion of earlier keys outside single quotes.
+	DialectGodotenv Dialect = iota
+
+	// DialectCompose follows docker compose .env files. Single-quoted values
+	// are literal; double-quoted values support \n, \r, \t, \\, \" and \$
+	// escapes and may span lines; unquoted values end at " #". ${VAR:-default},
+	// ${VAR-default}, ${VAR:?error}, ${VAR?error}, ${VAR:+alt} and ${VAR+alt}
+	// are expanded outside single quotes, and $$ is a literal dollar sign.
+	DialectCompose
+
+	// DialectSystemd follows systemd's EnvironmentFile=. Lines starting with
+	// "#" or ";" are comments and there are no inline comments. Quotes may
+	// appear anywhere in a value and may span lines; inside double quotes a
+	// backslash escapes only ", \, $, ` and newline. A backslash at the end of
+	// a line continues the value. Variables are never expanded.
+	DialectSystemd
+
+	// DialectPOSIX reads the file as a POSIX shell would source it: the value
+	// is a single shell word, with no spaces around "=". Quoting, backslash
+	// escapes, line continuations, $VAR, ${VAR} and the ${VAR:-default} family
+	// behave as in sh. Command substitution with $(...) or backticks is
+	// reported as an error rather than executed, as is unquoted whitespace
+	// inside a value.
+	DialectPOSIX
+)
+
+var dialectNames = map[Dialect]string{
+	DialectGodotenv: "godotenv",
+	DialectCompose:  "compose",
+	DialectSystemd:  "systemd",
+	DialectPOSIX:    "posix",
+}
+
+func (d Dialect) String() string {
+	if name, ok := dialectNames[d]; ok {
+		return name
+	}
+	return fmt.Sprintf("Dialect(%d)", int(d))
+}
+
+// errIncompleteValue reports that a value continues on the next line,
+// inside a quote or after a trailing backslash.
+var errIncompleteValue = errors.New("unterminated quote or line continuation")
+
+var (
+	shellNameRegex  = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*`)
+	shellKeyRegex   = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	dialectKeyRegex = regexp.MustCompile(`^\s*(?:export\s+)?([^=\s]*)\s*=\s*`)
+)
+
+// isDialectIgnoredLine reports whether line is blank or a full-line comment.
+func isDialectIgnoredLine(line string, dialect Dialect) bool {
+	trimmed := strings.TrimSpace(line)
+	return trimmed == "" || trimmed[0] == '#' || (dialect == DialectSystemd && trimmed[0] == ';')
+}
+
+// parseDialectEntry parses a KEY=value entry, which may span several lines
+// joined with "\n", using the rules of dialect. It returns errIncompleteValue
+// when the entry needs the next line. Variables are looked up in envMap and
+// then in the process environment.
+func parseDialectEntry(entry string, dialect Dialect, envMap map[string]string) (key, value string, err error) {
+	var rest string
+	switch dialect {
+	case DialectPOSIX:
+		trimmed := strings.TrimLeft(entry, " \t")
+		if strings.HasPrefix(trimmed, "export ") || strings.HasPrefix(trimmed, "export\t") {
+			trimmed = strings.TrimLeft(trimmed[len("export"):], " \t")
+		}
+		eq := strings.IndexByte(trimmed, '=')
+		if eq == -1 {
+			return "", "", errors.New("can't separate key from value")
+		}
+		key, rest = trimmed[:eq], trimmed[eq+1:]
+	case DialectSystemd:
+		eq := strings.IndexByte(entry, '=')
+		if eq == -1 {
+			return "", "", errors.New("can't separate key from value")
+		}
+		key, rest = strings.TrimSpace(entry[:eq]), strings.TrimLeft(entry[eq+1:], " \t")
+	default:
+		m := dialectKeyRegex.FindStringSubmatchIndex(entry)
+		if m == nil {
+			return "", "", errors.New("can't separate key from value")
+		}
+		key, rest = entry[m[2]:m[3]], entry[m[1]:]
+	}
+	if !shellKeyRegex.MatchString(key) {
+		return "", "", fmt.Errorf("invalid key %q", key)
+	}
+
+	lookup := func(name string) (string, bool) {
+		if v, ok := envMap[name]; ok {
+			return v, true
+		}
+		return os.LookupEnv(name)
+	}
+	s := &wordScanner{src: rest, lookup: lookup, dialect: dialect}
+	switch dialect {
+	case DialectCompose:
+		value, err = s.composeValue()
+	case DialectSystemd:
+		value, err = s.systemdValue()
+	case DialectPOSIX:
+		value, err = s.posixValue()
+	default:
+		err = fmt.Errorf("unknown dialect %s", dialect)
+	}
+	return key, value, err
+}
+
+// wordScanner reads a value under the quoting rules of a dialect.
+type wordScanner struct {
+	src     string
+	pos     int
+	lookup  func(name string) (string, bool)
+	dialect Dialect
+	sb      strings.Builder
+}
+
+func (s *wordScanner) eof() bool { return s.pos >= len(s.src) }
+
+// composeValue reads a docker compose value: quotes are only recognised at
+// the start of the value.
+func (s *wordScanner) composeValue() (string, error) {
+	switch {
+	case strings.HasPrefix(s.src, "'"):
+		end := strings.IndexByte(s.src[1:], '\'')
+		if end == -1 {
+			return "", errIncompleteValue
+		}
+		s.pos = end + 2
+		return s.src[1 : end+1], s.trailing()
+	case strings.HasPrefix(s.src, `"`):
+		s.pos = 1
+		if err := s.doubleQuoted(); err != nil {
+			return "", err
+		}
+		return s.sb.String(), s.trailing()
+	}
+
+	raw := s.src
+	for i := 1; i < len(raw); i++ {
+		if raw[i] == '#' && (raw[i-1] == ' ' || raw[i-1] == '\t') {
+			raw = raw[:i]
+			break
+		}
+	}
+	raw = strings.TrimRight(raw, " \t")
+	return s.expand(raw)
+}
+
+// expand returns text with its variable references expanded and no other
+// special characters.
+func (s *wordScanner) expand(text string) (string, error) {
+	ws := &wordScanner{src: text, lookup: s.lookup, dialect: s.dialect}
+	for !ws.eof() {
+		if ws.src[ws.pos] == '$' {
+			if err := ws.dollar(); err != nil {
+				return "", err
+			}
+			continue
+		}
+		ws.sb.WriteByte(ws.src[ws.pos])
+		ws.pos++
+	}
+	return ws.sb.String(), nil
+}
+
+// systemdValue reads a systemd EnvironmentFile value.
+func (s *wordScanner) systemdValue() (string, error) {
+	keep := 0 // length of the value without unquoted trailing whitespace
+	for !s.eof() {
+		c := s.src[s.pos]
+		switch c {
+		case '\'':
+			if err := s.singleQuoted(); err != nil {
+				return "", err
+			}
+		case '"':
+			s.pos++
+			if err := s.doubleQuoted(); err != nil {
+				return "", err
+			}
+		case '\\':
+			if err := s.backslash(); err != nil {
+				return "", err
+			}
+		default:
+			s.sb.WriteByte(c)
+			s.pos++
+			if c == ' ' || c == '\t' {
+				continue
+			}
+		}
+		keep = s.sb.Len()
+	}
+	return s.sb.String()[:keep], nil
+}
+
+// posixValue reads a single shell word followed by an optional comment.
+func (s *wordScanner) posixValue() (string, error) {
+	for !s.eof() {
+		c := s.src[s.pos]
+		switch c {
+		case ' ', '\t':
+			return s.sb.String(), s.trailing()
+		case '\'':
+			if err := s.singleQuoted(); err != nil {
+				return "", err
+			}
+		case '"':
+			s.pos++
+			if err := s.doubleQuoted(); err != nil {
+				return "", err
+			}
+		case '\\':
+			if err := s.backslash(); err != nil {
+				return "", err
+			}
+		case '$':
+			if err := s.dollar(); err != nil {
+				return "", err
+			}
+		case '`':
+			return "", errors.New("command substitution is not supported")
+		default:
+			s.sb.WriteByte(c)
+			s.pos++
+		}
+	}
+	return s.sb.String(), nil
+}
+
+// trailing checks that only whitespace and an optional comment follow a value.
+func (s *wordScanner) trailing() error {
+	rest := strings.TrimLeft(s.src[s.pos:], " \t")
+	if rest == "" || rest[0] == '#' {
+		return nil
+	}
+	if s.dialect == DialectPOSIX {
+		return fmt.Errorf("unexpected %q after value; quote values containing spaces", rest)
+	}
+	return fmt.Errorf("unexpected %q after quoted value", rest)
+}
+
+func (s *wordScanner) singleQuoted() error {
+	end := strings.IndexByte(s.src[s.pos+1:], '\'')
+	if end == -1 {
+		return errIncompleteValue
+	}
+	s.sb.WriteString(s.src[s.pos+1 : s.pos+1+end])
+	s.pos += end + 2
+	return nil
+}
+
+// doubleQuoted reads up to and including the closing double quote; the
+// opening quote has already been consumed.
+func (s *wordScanner) doubleQuoted() error {
+	for !s.eof() {
+		c := s.src[s.pos]
+		switch {
+		case c == '"':
+			s.pos++
+			return nil
+		case c == '\\':
+			if s.pos+1 >= len(s.src) {
+				return errIncompleteValue
+			}
+			s.writeDoubleQuotedEscape(s.src[s.pos+1])
+			s.pos += 2
+		case c == '$' && s.dialect != DialectSystemd:
+			if err := s.dollar(); err != nil {
+				return err
+			}
+		case c == '`' && s.dialect == DialectPOSIX:
+			return errors.New("command substitution is not supported")
+		default:
+			s.sb.WriteByte(c)
+			s.pos++
+		}
+	}
+	return errIncompleteValue
+}
+
+func (s *wordScanner) writeDoubleQuotedEscape(c byte) {
+	if s.dialect == DialectCompose {
+		switch c {
+		case 'n':
+			s.sb.WriteByte('\n')
+		case 'r':
+			s.sb.WriteByte('\r')
+		case 't':
+			s.sb.WriteByte('\t')
+		case '\\', '"', '$':
+			s.sb.WriteByte(c)
+		default:
+			s.sb.WriteByte('\\')
+			s.sb.WriteByte(c)
+		}
+		return
+	}
+
+	// systemd and sh only give a backslash meaning before these characters
+	switch c {
+	case '\n':
+	case '"', '\\', '$', '`':
+		s.sb.WriteByte(c)
+	default:
+		s.sb.WriteByte('\\')
+		s.sb.WriteByte(c)
+	}
+}
+
+// backslash handles an unquoted backslash: it escapes the next character, or
+// continues the value on the next line.
+func (s *wordScanner) backslash() error {
+	if s.pos+1 >= len(s.src) {
+		return errIncompleteValue
+	}
+	if c := s.src[s.pos+1]; c != '\n' {
+		s.sb.WriteByte(c)
+	}
+	s.pos += 2
+	return nil
+}
+
+// dollar expands the variable reference starting at the current "$".
+func (s *wordScanner) dollar() error {
+	rest := s.src[s.pos+1:]
+	switch {
+	case strings.HasPrefix(rest, "$") && s.dialect == DialectCompose:
+		s.sb.WriteByte('$')
+		s.pos += 2
+	case strings.HasPrefix(rest, "(") && s.dialect == DialectPOSIX:
+		return errors.New("command substitution is not supported")
+	case strings.HasPrefix(rest, "{"):
+		return s.braced()
+	default:
+		name := shellNameRegex.FindString(rest)
+		if name == "" {
+			s.sb.WriteByte('$')
+			s.pos++
+			return nil
+		}
+		value, _ := s.lookup(name)
+		s.sb.WriteString(value)
+		s.pos += 1 + len(name)
+	}
+	return nil
+}
+
+// braced expands ${NAME} and ${NAME<op>word} with the operators :-, -, :?,
+// ?, :+ and +.
+func (s *wordScanner) braced() error {
+	start := s.pos + 2
+	end, depth := -1, 1
+	for i := start; i < len(s.src) && end == -1; i++ {
+		switch s.src[i] {
+		case '{':
+			depth++
+		case '}':
+			if depth--; depth == 0 {
+				end = i
+			}
+		}
+	}
+	if end == -1 {
+		return errIncompleteValue
+	}
+	expr := s.src[start:end]
+	s.pos = end + 1
+
+	name := shellNameRegex.FindString(expr)
+	if name == "" {
+		return fmt.Errorf("bad substitution ${%s}", expr)
+	}
+	op := expr[len(name):]
+	value, set := s.lookup(name)
+	if op == "" {
+		s.sb.WriteString(value)
+		return nil
+	}
+
+	colon := strings.HasPrefix(op, ":")
+	if colon {
+		op = op[1:]
+	}
+	if op == "" {
+		return fmt.Errorf("bad substitution ${%s}", expr)
+	}
+	// with a colon, an empty variable counts as unset
+	present := set && (!colon || value != "")
+	word := func() (string, error) {
+		return s.expand(op[1:])
+	}
+
+	switch op[0] {
+	case '-':
+		if !present {
+			var err error
+			if value, err = word(); err != nil {
+				return err
+			}
+		}
+	case '+':
+		if !present {
+			value = ""
+		} else {
+			var err error
+			if value, err = word(); err != nil {
+				return err
+			}
+		}
+	case '?':
+		if !present {
+			msg, err := word()
+			if err != nil {
+				return err
+			}
+			if msg == "" {
+				msg = "parameter null or not set"
+			}
+			return fmt.Errorf("%s: %s", name, msg)
+		}
+	default:
+		return fmt.Errorf("bad substitution ${%s}", expr)
+	}
+	s.sb.WriteString(value)
+	return nil
+}
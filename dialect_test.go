@@ -0,0 +1,173 @@
+package env
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func parseDialect(t *testing.T, content string, dialect Dialect) map[string]string {
+	t.Helper()
+	envMap, err := ParseIOWithOptions(strings.NewReader(content), ParseOptions{Dialect: dialect})
+	if err != nil {
+		t.Fatalf("unexpected error parsing %s: %v", dialect, err)
+	}
+	return envMap
+}
+
+func dialectError(content string, dialect Dialect) error {
+	_, err := ParseIOWithOptions(strings.NewReader(content), ParseOptions{Dialect: dialect})
+	return err
+}
+
+func TestDialectDefaultIsGodotenv(t *testing.T) {
+	content := "A=1\nB: two\nC=\"x\\ny\" # comment\nD='$A'\nE=$A-${A}"
+	expected, err := ParseIO(strings.NewReader(content))
+	assert.NoError(t, err)
+	assert.Equal(t, expected, parseDialect(t, content, DialectGodotenv))
+	assert.Equal(t, "godotenv", DialectGodotenv.String())
+	assert.Equal(t, "Dialect(42)", Dialect(42).String())
+}
+
+func TestDialectCompose(t *testing.T) {
+	SetForTest(t, "DIALECT_FROM_PROCESS", "process")
+	UnsetForTest(t, "DIALECT_UNSET")
+
+	envMap := parseDialect(t, `# comment
+export EXPORTED=yes
+SPACED = value
+BARE=some value # comment
+HASH=no#comment
+SINGLE='literal $EXPORTED \n'
+DOUBLE="tab\there \"q\" \$EXPORTED $EXPORTED"
+MULTI="line one
+line two"
+DEFAULT=${DIALECT_UNSET:-fallback}
+EMPTY_DEFAULT=${EMPTY:-empty} ${EMPTY-kept}
+EMPTY=
+ALT=${EXPORTED:+alternate}
+PROCESS=${DIALECT_FROM_PROCESS}
+DOLLARS=$$EXPORTED
+`, DialectCompose)
+
+	assert.Equal(t, map[string]string{
+		"EXPORTED":      "yes",
+		"SPACED":        "value",
+		"BARE":          "some value",
+		"HASH":          "no#comment",
+		"SINGLE":        `literal $EXPORTED \n`,
+		"DOUBLE":        "tab\there \"q\" $EXPORTED yes",
+		"MULTI":         "line one\nline two",
+		"DEFAULT":       "fallback",
+		"EMPTY_DEFAULT": "empty kept",
+		"EMPTY":         "",
+		"ALT":           "alternate",
+		"PROCESS":       "process",
+		"DOLLARS":       "$EXPORTED",
+	}, envMap)
+
+	assert.EqualError(t, dialectError("A=${DIALECT_UNSET:?is required}", DialectCompose),
+		"line 1: DIALECT_UNSET: is required")
+	assert.EqualError(t, dialectError("A=1\nB=\"unterminated\nC=3", DialectCompose),
+		"line 2: unterminated quote or line continuation")
+	assert.Error(t, dialectError("A='x' y", DialectCompose))
+	assert.Error(t, dialectError("A B=1", DialectCompose))
+}
+
+func TestDialectSystemd(t *testing.T) {
+	envMap := parseDialect(t, `# comment
+; also a comment
+PLAIN=value with spaces
+HASH=value # not a comment
+SINGLE='literal \n $HOME'
+DOUBLE="keep \n, escape \" \\ \$"
+MIXED=one"two three"'four'
+TRAILING="space "
+CONTINUED=first \
+second
+ESCAPED=a\ b
+NOEXPAND=$HOME
+`, DialectSystemd)
+
+	assert.Equal(t, map[string]string{
+		"PLAIN":     "value with spaces",
+		"HASH":      "value # not a comment",
+		"SINGLE":    `literal \n $HOME`,
+		"DOUBLE":    `keep \n, escape " \ $`,
+		"MIXED":     "onetwo threefour",
+		"TRAILING":  "space ",
+		"CONTINUED": "first second",
+		"ESCAPED":   "a b",
+		"NOEXPAND":  "$HOME",
+	}, envMap)
+
+	assert.Error(t, dialectError("export A=1", DialectSystemd))
+}
+
+func TestDialectPOSIX(t *testing.T) {
+	SetForTest(t, "DIALECT_FROM_PROCESS", "process")
+	UnsetForTest(t, "DIALECT_UNSET")
+
+	envMap := parseDialect(t, `# comment
+export NAME=world
+GREETING="hello $NAME" # comment
+SINGLE='$NAME "quoted"'
+CONCAT=pre'fix '"$NAME"
+ESCAPED=a\ b\$c
+DQ_ESCAPES="\$x \n \"q\" \\"
+HASH=a#b
+MULTI='one
+two'
+CONTINUED=one\
+two
+DEFAULT=${DIALECT_UNSET:-${NAME}}
+PROCESS=$DIALECT_FROM_PROCESS
+LONE="cost: $"
+`, DialectPOSIX)
+
+	assert.Equal(t, map[string]string{
+		"NAME":       "world",
+		"GREETING":   "hello world",
+		"SINGLE":     `$NAME "quoted"`,
+		"CONCAT":     "prefix world",
+		"ESCAPED":    "a b$c",
+		"DQ_ESCAPES": `$x \n "q" \`,
+		"HASH":       "a#b",
+		"MULTI":      "one\ntwo",
+		"CONTINUED":  "onetwo",
+		"DEFAULT":    "world",
+		"PROCESS":    "process",
+		"LONE":       "cost: $",
+	}, envMap)
+
+	for _, invalid := range []string{
+		"A=$(whoami)",
+		"A=`whoami`",
+		`A="$(whoami)"`,
+		"A=two words",
+		"A = spaced",
+		"A=${unterminated",
+		"A=${1}",
+	} {
+		assert.Error(t, dialectError(invalid, DialectPOSIX), invalid)
+	}
+	assert.EqualError(t, dialectError("A=ok\nB=$(rm -rf /)", DialectPOSIX),
+		"line 2: command substitution is not supported")
+}
+
+func TestDialectsDecryptAndInclude(t *testing.T) {
+	key := newTestKey(t)
+	encrypted, _ := EncryptValue("s3cret", key)
+
+	root := makeTree(t, map[string]string{"common.env": "COMMON=1"})
+
+	envMap, err := ParseIOWithOptions(strings.NewReader("source common.env\nSECRET="+encrypted), ParseOptions{
+		Dialect:        DialectPOSIX,
+		IncludeFS:      os.DirFS(root),
+		EncryptionKeys: MapSource(map[string]string{EncryptionKeyVar: key}),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"COMMON": "1", "SECRET": "s3cret"}, envMap)
+}
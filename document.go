@@ -0,0 +1,252 @@
+package env
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Document is an editable view of a .env file that preserves comments, blank
+// lines, ordering and the original quoting of every line it does not touch.
+//
+// It is intended for tooling that needs to update a checked-in .env file
+// without regenerating it the way Write and Marshal do:
+//
+//	doc, err := env.ReadDocument(".env")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	doc.Set("API_URL", "https://api.example.com")
+//	doc.Delete("LEGACY_FLAG")
+//	err = doc.WriteFile(".env", env.WriteOptions{})
+//
+// Lines are parsed with the same grammar as ParseIO, so Get returns the same
+// value ParseIO would. Values are expanded once, when the document is parsed;
+// changing a value with Set does not re-expand later lines that reference it.
+type Document struct {
+	lines           []documentLine
+	newline         string
+	trailingNewline bool
+}
+
+// documentLine is a single line of a Document. Blank and comment lines have
+// an empty key and are written back verbatim.
+type documentLine struct {
+	raw   string
+	key   string
+	value string
+}
+
+// ParseDocument reads a .env document from an io.Reader.
+// It returns an error if any line cannot be parsed by ParseIO's rules.
+// Include directives are preserved as written; the keys of included files
+// are not part of the document.
+func ParseDocument(r io.Reader) (*Document, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	text := string(data)
+	doc := &Document{newline: "\n", trailingNewline: true}
+	if text == "" {
+		return doc, nil
+	}
+	if strings.Contains(text, "\r\n") {
+		doc.newline = "\r\n"
+	}
+	doc.trailingNewline = strings.HasSuffix(text, "\n")
+	text = strings.TrimSuffix(text, "\n")
+
+	envMap := make(map[string]string)
+	for i, raw := range strings.Split(text, "\n") {
+		raw = strings.TrimSuffix(raw, "\r")
+		line := documentLine{raw: raw}
+		if line.isEntry() {
+			line.key, line.value, err = parseLine(raw, envMap)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+			envMap[line.key] = line.value
+		}
+		doc.lines = append(doc.lines, line)
+	}
+	return doc, nil
+}
+
+// ReadDocument reads and parses the named .env file into a Document.
+func ReadDocument(filename string) (*Document, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ParseDocument(file)
+}
+
+// Get returns the value of key and whether it is present in the document.
+// If the key is defined more than once, the last definition wins, as in ParseIO.
+func (d *Document) Get(key string) (string, bool) {
+	i := d.index(key)
+	if i == -1 {
+		return "", false
+	}
+	return d.lines[i].value, true
+}
+
+// Set updates the value of key, rewriting only the line that defines it.
+// The line keeps its export prefix, separator and trailing comment.
+// If the key is not present, a new KEY=VALUE line is appended.
+// New values are quoted with QuoteValue.
+func (d *Document) Set(key, value string) {
+	i := d.index(key)
+	if i == -1 {
+		d.lines = append(d.lines, documentLine{
+			raw:   key + "=" + QuoteValue(value),
+			key:   key,
+			value: value,
+		})
+		return
+	}
+	d.lines[i].setValue(value)
+}
+
+// Delete removes every line that defines key. Comments above the key are
+// left in place. It reports whether anything was removed.
+func (d *Document) Delete(key string) bool {
+	lines := d.lines[:0]
+	for _, line := range d.lines {
+		if !line.isEntry() || line.key != key {
+			lines = append(lines, line)
+		}
+	}
+	removed := len(lines) != len(d.lines)
+	d.lines = lines
+	return removed
+}
+
+// rewriteValues calls fn for every line that defines a key, including
+// duplicates, and rewrites the lines whose value fn changes. It stops at the
+// first error.
+func (d *Document) rewriteValues(fn func(key, value string) (string, error)) error {
+	for i := range d.lines {
+		line := &d.lines[i]
+		if !line.isEntry() {
+			continue
+		}
+		value, err := fn(line.key, line.value)
+		if err != nil {
+			return err
+		}
+		if value != line.value {
+			line.setValue(value)
+		}
+	}
+	return nil
+}
+
+// Keys returns the keys defined in the document in the order they first appear.
+func (d *Document) Keys() []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, line := range d.lines {
+		if line.isEntry() && !seen[line.key] {
+			seen[line.key] = true
+			keys = append(keys, line.key)
+		}
+	}
+	return keys
+}
+
+// Comment returns the block of comment lines directly above the definition of
+// key, with the leading "#" (and one following space) removed from each line.
+// A blank line ends the block. It returns an empty string if there is none.
+func (d *Document) Comment(key string) string {
+	i := d.index(key)
+	if i == -1 {
+		return ""
+	}
+
+	var comments []string
+	for j := i - 1; j >= 0; j-- {
+		trimmed := strings.TrimSpace(d.lines[j].raw)
+		if !strings.HasPrefix(trimmed, "#") {
+			break
+		}
+		trimmed = strings.TrimPrefix(trimmed, "#")
+		comments = append([]string{strings.TrimPrefix(trimmed, " ")}, comments...)
+	}
+	return strings.Join(comments, "\n")
+}
+
+// WriteTo writes the document to w. Untouched lines are written exactly as
+// they were read, using the document's original line endings.
+// It implements io.WriterTo.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	var sb strings.Builder
+	for i, line := range d.lines {
+		if i > 0 {
+			sb.WriteString(d.newline)
+		}
+		sb.WriteString(line.raw)
+	}
+	if len(d.lines) > 0 && d.trailingNewline {
+		sb.WriteString(d.newline)
+	}
+
+	n, err := io.WriteString(w, sb.String())
+	return int64(n), err
+}
+
+// WriteFile writes the document to filename the way WriteFile writes a map:
+// through a temporary file that is renamed over filename, so the original is
+// never left partially written. A zero opts.Perm keeps the permissions of the
+// existing file.
+func (d *Document) WriteFile(filename string, opts WriteOptions) error {
+	var buf bytes.Buffer
+	if _, err := d.WriteTo(&buf); err != nil {
+		return err
+	}
+	return writeFileAtomic(filename, buf.Bytes(), opts)
+}
+
+// index returns the position of the last line defining key, or -1.
+func (d *Document) index(key string) int {
+	for i := len(d.lines) - 1; i >= 0; i-- {
+		if d.lines[i].isEntry() && d.lines[i].key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// isEntry reports whether the line defines a key. Include directives are
+// kept as they are written and are not followed.
+func (l *documentLine) isEntry() bool {
+	if _, ok := includeTarget(l.raw); ok {
+		return false
+	}
+	return !isIgnoredLine(l.raw)
+}
+
+// setValue replaces the value portion of the line, keeping everything before
+// the value (indentation, export, key, separator) and any trailing comment.
+func (l *documentLine) setValue(value string) {
+	code := stripComment(l.raw)
+	suffix := ""
+	if strings.HasPrefix(l.raw, code) {
+		suffix = l.raw[len(code):]
+	}
+	trimmed := strings.TrimRight(code, " \t")
+	suffix = code[len(trimmed):] + suffix
+
+	sep := separatorIndex(trimmed)
+	rest := trimmed[sep+1:]
+	prefix := trimmed[:sep+1] + rest[:len(rest)-len(strings.TrimLeft(rest, " \t"))]
+
+	l.raw = prefix + QuoteValue(value) + suffix
+	l.value = value
+}
@@ -0,0 +1,144 @@
+package env
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const documentFixture = `# Database settings
+# (shared with the worker)
+DB_HOST=localhost
+export DB_PORT = 5432 # default postgres port
+
+API_KEY='abc#123'
+DB_HOST=db.internal
+`
+
+func writeDocument(t *testing.T, doc *Document) string {
+	t.Helper()
+	var buf bytes.Buffer
+	n, err := doc.WriteTo(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(buf.Len()), n)
+	return buf.String()
+}
+
+func TestDocumentRoundtripsUntouched(t *testing.T) {
+	doc, err := ParseDocument(strings.NewReader(documentFixture))
+	assert.NoError(t, err)
+	assert.Equal(t, documentFixture, writeDocument(t, doc))
+}
+
+func TestDocumentMatchesParseIO(t *testing.T) {
+	doc, err := ParseDocument(strings.NewReader(documentFixture))
+	assert.NoError(t, err)
+	envMap, err := Unmarshal(documentFixture)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"DB_HOST", "DB_PORT", "API_KEY"}, doc.Keys())
+	for key, expected := range envMap {
+		value, ok := doc.Get(key)
+		assert.True(t, ok)
+		assert.Equal(t, expected, value, key)
+	}
+
+	_, ok := doc.Get("MISSING")
+	assert.False(t, ok)
+}
+
+func TestDocumentSet(t *testing.T) {
+	doc, err := ParseDocument(strings.NewReader(documentFixture))
+	assert.NoError(t, err)
+
+	doc.Set("DB_PORT", "6543")
+	doc.Set("DB_HOST", `db "two"`)
+	doc.Set("NEW_KEY", "new value")
+
+	expected := `# Database settings
+# (shared with the worker)
+DB_HOST=localhost
+export DB_PORT = 6543 # default postgres port
+
+API_KEY='abc#123'
+DB_HOST='db "two"'
+NEW_KEY='new value'
+`
+	actual := writeDocument(t, doc)
+	assert.Equal(t, expected, actual)
+
+	envMap, err := Unmarshal(actual)
+	assert.NoError(t, err)
+	assert.Equal(t, "6543", envMap["DB_PORT"])
+	assert.Equal(t, `db "two"`, envMap["DB_HOST"])
+	assert.Equal(t, "new value", envMap["NEW_KEY"])
+}
+
+func TestDocumentWriteFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), ".env")
+	assert.NoError(t, os.WriteFile(filename, []byte(documentFixture), 0640))
+
+	doc, err := ReadDocument(filename)
+	assert.NoError(t, err)
+	doc.Set("API_KEY", "rotated")
+	assert.NoError(t, doc.WriteFile(filename, WriteOptions{}))
+
+	data, err := os.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Replace(documentFixture, "'abc#123'", "rotated", 1), string(data))
+	info, err := os.Stat(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+}
+
+func TestDocumentDelete(t *testing.T) {
+	doc, err := ParseDocument(strings.NewReader(documentFixture))
+	assert.NoError(t, err)
+
+	assert.True(t, doc.Delete("DB_HOST"))
+	assert.False(t, doc.Delete("DB_HOST"))
+
+	expected := `# Database settings
+# (shared with the worker)
+export DB_PORT = 5432 # default postgres port
+
+API_KEY='abc#123'
+`
+	assert.Equal(t, expected, writeDocument(t, doc))
+	assert.Equal(t, []string{"DB_PORT", "API_KEY"}, doc.Keys())
+}
+
+func TestDocumentComment(t *testing.T) {
+	doc, err := ParseDocument(strings.NewReader("# first\n#second\nA=1\n\nB=2\n# unrelated\n\nC=3"))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "first\nsecond", doc.Comment("A"))
+	assert.Equal(t, "", doc.Comment("B"))
+	assert.Equal(t, "", doc.Comment("C"))
+	assert.Equal(t, "", doc.Comment("MISSING"))
+}
+
+func TestDocumentLineEndings(t *testing.T) {
+	doc, err := ParseDocument(strings.NewReader("A=1\r\nB=2"))
+	assert.NoError(t, err)
+
+	doc.Set("C", "3")
+	assert.Equal(t, "A=1\r\nB=2\r\nC=3", writeDocument(t, doc))
+
+	doc, err = ParseDocument(strings.NewReader(""))
+	assert.NoError(t, err)
+	doc.Set("A", "1")
+	assert.Equal(t, "A=1\n", writeDocument(t, doc))
+}
+
+func TestDocumentParseError(t *testing.T) {
+	_, err := ParseDocument(strings.NewReader("A=1\nINVALID LINE\n"))
+	assert.EqualError(t, err, "line 2: can't separate key from value")
+
+	_, err = ReadDocument("somefilethatwillneverexistever.env")
+	assert.Error(t, err)
+}
@@ -0,0 +1,225 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Day and Week are the lengths of the d and w units of ParseExtendedDuration.
+// They are always 24 and 168 hours; daylight saving time is not considered.
+const (
+	Day  = 24 * time.Hour
+	Week = 7 * Day
+)
+
+var (
+	extendedDurationRegex = regexp.MustCompile(`([0-9]*(?:\.[0-9]*)?)([a-zA-Zµμ]+)`)
+	iso8601DurationRegex  = regexp.MustCompile(`^P(?:([0-9.,]+)W)?(?:([0-9.,]+)D)?(?:T(?:([0-9.,]+)H)?(?:([0-9.,]+)M)?(?:([0-9.,]+)S)?)?$`)
+	iso8601CalendarRegex  = regexp.MustCompile(`^P[^T]*[YM]`)
+)
+
+// ParseExtendedDuration parses a duration in the syntax of time.ParseDuration,
+// extended with the units d (24 hours) and w (7 days), as in "7d", "2w" or
+// "1w2d12h". It also accepts ISO 8601 durations; see ParseISO8601Duration.
+func ParseExtendedDuration(s string) (time.Duration, error) {
+	unsigned := strings.TrimLeft(s, "+-")
+	if strings.HasPrefix(unsigned, "P") {
+		return ParseISO8601Duration(s)
+	}
+	if !strings.ContainsAny(unsigned, "dw") {
+		return time.ParseDuration(s)
+	}
+
+	sign := time.Duration(1)
+	if strings.HasPrefix(s, "-") {
+		sign = -1
+	}
+	if len(s)-len(unsigned) > 1 {
+		return 0, invalidDuration(s)
+	}
+
+	var total time.Duration
+	rest := unsigned
+	for rest != "" {
+		m := extendedDurationRegex.FindStringSubmatchIndex(rest)
+		if m == nil || m[0] != 0 || m[3] == m[2] {
+			return 0, invalidDuration(s)
+		}
+		number, unit := rest[m[2]:m[3]], rest[m[4]:m[5]]
+		rest = rest[m[1]:]
+
+		var part time.Duration
+		var err error
+		switch unit {
+		case "d":
+			part, err = scaleDuration(number, Day)
+		case "w":
+			part, err = scaleDuration(number, Week)
+		default:
+			part, err = time.ParseDuration(number + unit)
+		}
+		if err != nil {
+			return 0, invalidDuration(s)
+		}
+		if total > math.MaxInt64-part {
+			return 0, fmt.Errorf("time: invalid duration %q: overflows time.Duration", s)
+		}
+		total += part
+	}
+	return sign * total, nil
+}
+
+// ParseISO8601Duration parses an ISO 8601 duration such as "P1DT2H",
+// "PT30M", "P2W" or "-PT1.5S". Years and months are rejected because they
+// have no fixed length.
+func ParseISO8601Duration(s string) (time.Duration, error) {
+	sign := time.Duration(1)
+	unsigned := s
+	switch {
+	case strings.HasPrefix(s, "-"):
+		sign, unsigned = -1, s[1:]
+	case strings.HasPrefix(s, "+"):
+		unsigned = s[1:]
+	}
+
+	if iso8601CalendarRegex.MatchString(unsigned) {
+		return 0, fmt.Errorf("time: invalid duration %q: ISO 8601 years and months have no fixed length", s)
+	}
+	m := iso8601DurationRegex.FindStringSubmatch(unsigned)
+	if m == nil || strings.HasSuffix(unsigned, "T") || unsigned == "P" {
+		return 0, invalidDuration(s)
+	}
+
+	var total time.Duration
+	for i, unit := range []time.Duration{Week, Day, time.Hour, time.Minute, time.Second} {
+		if m[i+1] == "" {
+			continue
+		}
+		part, err := scaleDuration(strings.Replace(m[i+1], ",", ".", 1), unit)
+		if err != nil || total > math.MaxInt64-part {
+			return 0, invalidDuration(s)
+		}
+		total += part
+	}
+	return sign * total, nil
+}
+
+// scaleDuration returns number units, where number is a decimal such as
+// "1.5", keeping the precision of time.ParseDuration.
+func scaleDuration(number string, unit time.Duration) (time.Duration, error) {
+	if number == "" || number == "." {
+		return 0, errInvalidDuration
+	}
+	switch unit {
+	case time.Second:
+		return time.ParseDuration(number + "s")
+	case time.Minute:
+		return time.ParseDuration(number + "m")
+	}
+
+	hours, err := time.ParseDuration(number + "h")
+	if err != nil {
+		return 0, err
+	}
+	factor := unit / time.Hour
+	if hours > math.MaxInt64/factor {
+		return 0, errInvalidDuration
+	}
+	return hours * factor, nil
+}
+
+var errInvalidDuration = errors.New("invalid duration")
+
+func invalidDuration(s string) error {
+	return fmt.Errorf("time: invalid duration %q", s)
+}
+
+// DurationFormat selects the syntax of time.Duration struct fields, with the
+// envDurationFormat tag:
+//
+//	type Config struct {
+//		Retention time.Duration `env:"RETENTION" envDurationFormat:"extended"` // 7d, 2w, P1DT2H
+//	}
+type DurationFormat int
+
+const (
+	// DurationStandard is the syntax of time.ParseDuration, such as "1h30m".
+	DurationStandard DurationFormat = iota
+	// DurationExtended adds the d and w units and ISO 8601 durations; see
+	// ParseExtendedDuration.
+	DurationExtended
+	// DurationISO8601 accepts only ISO 8601 durations, such as "P1DT2H".
+	DurationISO8601
+)
+
+var durationFormatNames = map[string]DurationFormat{
+	"standard": DurationStandard,
+	"extended": DurationExtended,
+	"iso8601":  DurationISO8601,
+}
+
+// Parser returns the Parser for durations in format f.
+func (f DurationFormat) Parser() Parser[time.Duration] {
+	switch f {
+	case DurationExtended:
+		return ParseExtendedDuration
+	case DurationISO8601:
+		return ParseISO8601Duration
+	}
+	return ParseDuration
+}
+
+// fieldDurationParser reads the envDurationFormat tag of a field.
+func fieldDurationParser(field reflect.StructField) (Parser[time.Duration], error) {
+	tag := field.Tag.Get("envDurationFormat")
+	if tag == "" {
+		return ParseDuration, nil
+	}
+	format, ok := durationFormatNames[tag]
+	if !ok {
+		return nil, fmt.Errorf("invalid envDurationFormat tag %q: must be standard, extended or iso8601", tag)
+	}
+	return format.Parser(), nil
+}
+
+// GetExtendedDuration retrieves an environment variable and parses it as a time.Duration,
+// accepting the d and w units and ISO 8601 durations as well as the syntax of
+// time.ParseDuration. See ParseExtendedDuration.
+func GetExtendedDuration(key string) (time.Duration, error) {
+	return defaultEnvironment.GetExtendedDuration(key)
+}
+
+// GetOrExtendedDuration retrieves an environment variable and parses it as an extended
+// duration. If the variable is not set or parsing fails, it returns the default value.
+// Unlike GetOrDuration, the default is a time.Duration, as for the other GetOr* functions.
+func GetOrExtendedDuration(key string, defaultValue time.Duration) time.Duration {
+	return defaultEnvironment.GetOrExtendedDuration(key, defaultValue)
+}
+
+// MustGetExtendedDuration retrieves an environment variable and parses it as an extended
+// duration. If the variable is not set or parsing fails, it panics.
+func MustGetExtendedDuration(key string) time.Duration {
+	return defaultEnvironment.MustGetExtendedDuration(key)
+}
+
+// GetExtendedDuration parses the value of key with ParseExtendedDuration.
+func (e *Environment) GetExtendedDuration(key string) (time.Duration, error) {
+	return GetParsedFrom(e, key, ParseExtendedDuration)
+}
+
+// GetOrExtendedDuration parses the value of key with ParseExtendedDuration,
+// returning defaultValue if it is not set or invalid.
+func (e *Environment) GetOrExtendedDuration(key string, defaultValue time.Duration) time.Duration {
+	return GetOrParsedFrom(e, key, defaultValue, ParseExtendedDuration)
+}
+
+// MustGetExtendedDuration parses the value of key with
+// ParseExtendedDuration, panicking if it is not set or invalid.
+func (e *Environment) MustGetExtendedDuration(key string) time.Duration {
+	return MustGetParsedFrom(e, key, ParseExtendedDuration, "time.Duration")
+}
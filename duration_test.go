@@ -0,0 +1,122 @@
+package env
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseExtendedDuration(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"0", 0},
+		{"90s", 90 * time.Second},
+		{"1h30m", 90 * time.Minute},
+		{"7d", 7 * Day},
+		{"2w", 2 * Week},
+		{"1w2d12h", Week + 2*Day + 12*time.Hour},
+		{"1.5d", 36 * time.Hour},
+		{"-3d", -3 * Day},
+		{"+1d1ms", Day + time.Millisecond},
+		{"P1DT2H", Day + 2*time.Hour},
+		{"PT30M", 30 * time.Minute},
+		{"P2W", 2 * Week},
+		{"-PT1.5S", -1500 * time.Millisecond},
+		{"PT0,5H", 30 * time.Minute},
+		{"P1W1DT1H1M1S", Week + Day + time.Hour + time.Minute + time.Second},
+	}
+	for _, tt := range tests {
+		got, err := ParseExtendedDuration(tt.in)
+		if assert.NoError(t, err, tt.in) {
+			assert.Equal(t, tt.want, got, tt.in)
+		}
+	}
+}
+
+func TestParseExtendedDurationErrors(t *testing.T) {
+	for _, in := range []string{"", "d", "7", "7x", "7dd", "--1d", "1d 2h", "P", "PT", "P1H", "PT1D", "P1.2.3D", "1.d2"} {
+		_, err := ParseExtendedDuration(in)
+		assert.Error(t, err, in)
+	}
+
+	_, err := ParseExtendedDuration("P1Y")
+	assert.EqualError(t, err, `time: invalid duration "P1Y": ISO 8601 years and months have no fixed length`)
+	_, err = ParseExtendedDuration("P1M")
+	assert.Error(t, err)
+
+	_, err = ParseExtendedDuration("15300w")
+	assert.Error(t, err, "overflow")
+	_, err = ParseExtendedDuration("15000w2000w")
+	assert.EqualError(t, err, `time: invalid duration "15000w2000w": overflows time.Duration`)
+}
+
+func TestParseISO8601Duration(t *testing.T) {
+	d, err := ParseISO8601Duration("P1DT12H")
+	assert.NoError(t, err)
+	assert.Equal(t, 36*time.Hour, d)
+
+	_, err = ParseISO8601Duration("1d")
+	assert.Error(t, err)
+}
+
+func TestExtendedDurationFuncs(t *testing.T) {
+	SetForTest(t, "RETENTION", "7d")
+	SetForTest(t, "BAD_RETENTION", "a week")
+	UnsetForTest(t, "ENV_NO_EXISTS")
+
+	d, err := GetExtendedDuration("RETENTION")
+	assert.NoError(t, err)
+	assert.Equal(t, 7*Day, d)
+	assert.Equal(t, 7*Day, MustGetExtendedDuration("RETENTION"))
+	assert.Equal(t, 7*Day, GetOrExtendedDuration("RETENTION", Day))
+
+	_, err = GetDuration("RETENTION")
+	assert.Error(t, err, "GetDuration keeps time.ParseDuration syntax")
+
+	_, err = GetExtendedDuration("ENV_NO_EXISTS")
+	assert.ErrorIs(t, err, ErrNotSet)
+	assert.Equal(t, Day, GetOrExtendedDuration("ENV_NO_EXISTS", Day))
+	assert.Equal(t, Week, GetOrExtendedDuration("BAD_RETENTION", Week))
+	assert.Panics(t, func() { MustGetExtendedDuration("BAD_RETENTION") })
+}
+
+func TestDurationFormatTags(t *testing.T) {
+	type config struct {
+		Retention time.Duration   `env:"DURATION_TAG_RETENTION" envDurationFormat:"extended"`
+		Interval  time.Duration   `env:"DURATION_TAG_INTERVAL" envDurationFormat:"iso8601"`
+		Timeout   time.Duration   `env:"DURATION_TAG_TIMEOUT" envDurationFormat:"standard"`
+		Windows   []time.Duration `env:"DURATION_TAG_WINDOWS" envDurationFormat:"extended"`
+		Default   time.Duration   `env:"DURATION_TAG_DEFAULT" envDurationFormat:"extended" envDefault:"2w"`
+	}
+	SetForTest(t, "DURATION_TAG_RETENTION", "30d")
+	SetForTest(t, "DURATION_TAG_INTERVAL", "PT15M")
+	SetForTest(t, "DURATION_TAG_TIMEOUT", "5s")
+	SetForTest(t, "DURATION_TAG_WINDOWS", "1h,1d,1w")
+	UnsetForTest(t, "DURATION_TAG_DEFAULT")
+
+	var cfg config
+	assert.NoError(t, Parse(&cfg))
+	assert.Equal(t, config{
+		Retention: 30 * Day,
+		Interval:  15 * time.Minute,
+		Timeout:   5 * time.Second,
+		Windows:   []time.Duration{time.Hour, Day, Week},
+		Default:   2 * Week,
+	}, cfg)
+
+	SetForTest(t, "DURATION_TAG_INTERVAL", "15m")
+	assert.Error(t, Parse(&config{}))
+
+	var badTag struct {
+		Value time.Duration `env:"DURATION_TAG_TIMEOUT" envDurationFormat:"human"`
+	}
+	assert.ErrorContains(t, Parse(&badTag), `invalid envDurationFormat tag "human"`)
+
+	var untagged struct {
+		Value time.Duration `env:"DURATION_TAG_RETENTION"`
+	}
+	assert.Error(t, Parse(&untagged))
+}
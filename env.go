@@ -68,7 +68,10 @@ var (
 	sliceOfFloat32s  = reflect.TypeOf([]float32(nil))
 	sliceOfFloat64s  = reflect.TypeOf([]float64(nil))
 	sliceOfDurations = reflect.TypeOf([]time.Duration(nil))
+	sliceOfByteSizes = reflect.TypeOf([]ByteSize(nil))
 	sliceOfURLs      = reflect.TypeOf([]url.URL(nil))
+	sliceOfTimes     = reflect.TypeOf([]time.Time(nil))
+	sliceOfLocations = reflect.TypeOf([]*time.Location(nil))
 )
 
 // CustomParsers maps Go types to custom parsing functions.
@@ -95,6 +98,10 @@ type ParserFunc func(v string) (interface{}, error)
 //   - envDefault:"value" - default value if the environment variable is not set
 //   - required:"true" - makes the field required (causes error if missing)
 //   - envSeparator:"," - separator for slice types (default is comma)
+//   - envBase:"16" - base for integer types, or 0 for Go-style 0x, 0o and 0b prefixes
+//   - envNumberFormat:"prefixes,si,iec" - opt-in integer syntax (see NumberFormat)
+//   - envDurationFormat:"extended" - accept 7d, 2w and ISO 8601 durations (see DurationFormat)
+//   - envLayout:"2006-01-02" - layout for time.Time fields, RFC3339 by default (see ParseTime)
 //   - envExpand:"true" - enables variable expansion using os.ExpandEnv
 //
 // The function supports nested structs and pointers to structs.
@@ -169,7 +176,7 @@ func doParse(ref reflect.Value, structType reflect.Type, fieldPath string, prefi
 			currentPath = fieldPath + "." + currentPath
 		}
 
-		if reflect.Ptr == refField.Kind() && !refField.IsNil() && refField.CanSet() {
+		if reflect.Ptr == refField.Kind() && !refField.IsNil() && refField.CanSet() && refField.Type() != locationType {
 			err := ParseWithPrefixFuncs(refField.Interface(), prefix, funcMap)
 			if nil != err {
 				return err
@@ -250,6 +257,25 @@ func get(field reflect.StructField, prefix string) (string, error) {
 	return value, nil
 }
 
+// fieldOptions holds the parsing options selected by a field's tags.
+type fieldOptions struct {
+	numbers   NumberFormat
+	durations Parser[time.Duration]
+	times     Parser[time.Time]
+}
+
+func parseFieldOptions(field reflect.StructField) (fieldOptions, error) {
+	numbers, err := fieldNumberFormat(field)
+	if err != nil {
+		return fieldOptions{}, err
+	}
+	durations, err := fieldDurationParser(field)
+	if err != nil {
+		return fieldOptions{}, err
+	}
+	return fieldOptions{numbers: numbers, durations: durations, times: fieldTimeParser(field)}, nil
+}
+
 func set(field reflect.Value, refType reflect.StructField, value string, funcMap CustomParsers) error {
 	// use custom parser if configured for this type
 	parserFunc, ok := funcMap[refType.Type]
@@ -271,27 +297,58 @@ func set(field reflect.Value, refType reflect.StructField, value string, funcMap
 		return nil
 	}
 
+	if refType.Type == reflect.TypeOf(ByteSize(0)) {
+		size, err := ParseByteSize(value)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(size))
+		return nil
+	}
+
+	opts, err := parseFieldOptions(refType)
+	if err != nil {
+		return err
+	}
+
+	switch refType.Type {
+	case timeType:
+		t, err := opts.times(value)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(t))
+		return nil
+	case locationType:
+		loc, err := ParseLocation(value)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(loc))
+		return nil
+	}
+
 	// fall back to built-in parsers
 	switch field.Kind() {
 	case reflect.Slice:
 		separator := refType.Tag.Get("envSeparator")
-		return handleSlice(field, value, separator)
+		return handleSlice(field, value, separator, opts)
 	case reflect.String:
 		field.SetString(value)
 	case reflect.Bool:
-		bvalue, err := strconv.ParseBool(value)
+		bvalue, err := ParseBool(value)
 		if err != nil {
 			return err
 		}
 		field.SetBool(bvalue)
 	case reflect.Int:
-		intValue, err := strconv.ParseInt(value, DecimalBase, Int32Bits)
+		intValue, err := ParseIntFormat(value, opts.numbers, Int32Bits)
 		if err != nil {
 			return err
 		}
 		field.SetInt(intValue)
 	case reflect.Uint:
-		uintValue, err := strconv.ParseUint(value, DecimalBase, Int32Bits)
+		uintValue, err := ParseUintFormat(value, opts.numbers, Int32Bits)
 		if err != nil {
 			return err
 		}
@@ -310,20 +367,20 @@ func set(field reflect.Value, refType reflect.StructField, value string, funcMap
 		field.Set(reflect.ValueOf(v))
 	case reflect.Int64:
 		if refType.Type.String() == "time.Duration" {
-			dValue, err := time.ParseDuration(value)
+			dValue, err := opts.durations(value)
 			if err != nil {
 				return err
 			}
 			field.Set(reflect.ValueOf(dValue))
 		} else {
-			intValue, err := strconv.ParseInt(value, DecimalBase, Int64Bits)
+			intValue, err := ParseIntFormat(value, opts.numbers, Int64Bits)
 			if err != nil {
 				return err
 			}
 			field.SetInt(intValue)
 		}
 	case reflect.Uint64:
-		uintValue, err := strconv.ParseUint(value, DecimalBase, Int64Bits)
+		uintValue, err := ParseUintFormat(value, opts.numbers, Int64Bits)
 		if err != nil {
 			return err
 		}
@@ -334,7 +391,7 @@ func set(field reflect.Value, refType reflect.StructField, value string, funcMap
 	return nil
 }
 
-func handleSlice(field reflect.Value, value, separator string) error {
+func handleSlice(field reflect.Value, value, separator string, opts fieldOptions) error {
 	if separator == "" {
 		separator = ","
 	}
@@ -345,19 +402,19 @@ func handleSlice(field reflect.Value, value, separator string) error {
 	case sliceOfStrings:
 		field.Set(reflect.ValueOf(splitData))
 	case sliceOfInts:
-		intData, err := parseInts(splitData)
+		intData, err := parseInts(splitData, opts.numbers)
 		if err != nil {
 			return err
 		}
 		field.Set(reflect.ValueOf(intData))
 	case sliceOfInt64s:
-		int64Data, err := parseInt64s(splitData)
+		int64Data, err := parseInt64s(splitData, opts.numbers)
 		if err != nil {
 			return err
 		}
 		field.Set(reflect.ValueOf(int64Data))
 	case sliceOfUint64s:
-		uint64Data, err := parseUint64s(splitData)
+		uint64Data, err := parseUint64s(splitData, opts.numbers)
 		if err != nil {
 			return err
 		}
@@ -381,7 +438,7 @@ func handleSlice(field reflect.Value, value, separator string) error {
 		}
 		field.Set(reflect.ValueOf(boolData))
 	case sliceOfDurations:
-		durationData, err := parseDurations(splitData)
+		durationData, err := parseDurations(splitData, opts.durations)
 		if err != nil {
 			return err
 		}
@@ -392,6 +449,24 @@ func handleSlice(field reflect.Value, value, separator string) error {
 			return err
 		}
 		field.Set(reflect.ValueOf(urlData))
+	case sliceOfByteSizes:
+		sizeData, err := parseSlice(splitData, ParseByteSize)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(sizeData))
+	case sliceOfTimes:
+		timeData, err := parseSlice(splitData, opts.times)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(timeData))
+	case sliceOfLocations:
+		locationData, err := parseSlice(splitData, ParseLocation)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(locationData))
 	default:
 		elemType := field.Type().Elem()
 		// Ensure we test *type as we can always address elements in a slice.
@@ -424,111 +499,42 @@ func handleTextUnmarshaler(field reflect.Value, value string) error {
 	return tm.UnmarshalText([]byte(value))
 }
 
-func parseInts(data []string) ([]int, error) {
-	intSlice := make([]int, 0, len(data))
-
-	for _, v := range data {
-		intValue, err := strconv.ParseInt(v, DecimalBase, Int32Bits)
-		if err != nil {
-			return nil, err
-		}
-		intSlice = append(intSlice, int(intValue))
-	}
-	return intSlice, nil
+func parseInts(data []string, format NumberFormat) ([]int, error) {
+	return parseSlice(data, IntParser(format))
 }
 
-func parseInt64s(data []string) ([]int64, error) {
-	intSlice := make([]int64, 0, len(data))
-
-	for _, v := range data {
-		intValue, err := strconv.ParseInt(v, DecimalBase, Int64Bits)
-		if err != nil {
-			return nil, err
-		}
-		intSlice = append(intSlice, int64(intValue))
-	}
-	return intSlice, nil
+func parseInt64s(data []string, format NumberFormat) ([]int64, error) {
+	return parseSlice(data, Int64Parser(format))
 }
 
-func parseUint64s(data []string) ([]uint64, error) {
-	uintSlice := make([]uint64, 0, len(data))
-
-	for _, v := range data {
-		uintValue, err := strconv.ParseUint(v, DecimalBase, Int64Bits)
-		if err != nil {
-			return nil, err
-		}
-		uintSlice = append(uintSlice, uint64(uintValue))
-	}
-	return uintSlice, nil
+func parseUint64s(data []string, format NumberFormat) ([]uint64, error) {
+	return parseSlice(data, Uint64Parser(format))
 }
 
 func parseFloat32s(data []string) ([]float32, error) {
-	float32Slice := make([]float32, 0, len(data))
-
-	for _, v := range data {
-		data, err := strconv.ParseFloat(v, Float32Bits)
-		if err != nil {
-			return nil, err
-		}
-		float32Slice = append(float32Slice, float32(data))
-	}
-	return float32Slice, nil
+	return parseSlice(data, ParseFloat32)
 }
 
 func parseFloat64s(data []string) ([]float64, error) {
-	float64Slice := make([]float64, 0, len(data))
-
-	for _, v := range data {
-		data, err := strconv.ParseFloat(v, Float64Bits)
-		if err != nil {
-			return nil, err
-		}
-		float64Slice = append(float64Slice, float64(data))
-	}
-	return float64Slice, nil
+	return parseSlice(data, ParseFloat64)
 }
 
 func parseBools(data []string) ([]bool, error) {
-	boolSlice := make([]bool, 0, len(data))
-
-	for _, v := range data {
-		bvalue, err := strconv.ParseBool(v)
-		if err != nil {
-			return nil, err
-		}
-
-		boolSlice = append(boolSlice, bvalue)
-	}
-	return boolSlice, nil
+	return parseSlice(data, ParseBool)
 }
 
-func parseDurations(data []string) ([]time.Duration, error) {
-	durationSlice := make([]time.Duration, 0, len(data))
-
-	for _, v := range data {
-		dvalue, err := time.ParseDuration(v)
-		if err != nil {
-			return nil, err
-		}
-
-		durationSlice = append(durationSlice, dvalue)
-	}
-	return durationSlice, nil
+func parseDurations(data []string, parser Parser[time.Duration]) ([]time.Duration, error) {
+	return parseSlice(data, parser)
 }
 
 func parseUrls(data []string) ([]url.URL, error) {
-	urlSlice := make([]url.URL, 0, len(data))
-
-	for _, v := range data {
-		uvalue, err := url.Parse(v)
+	return parseSlice(data, func(s string) (url.URL, error) {
+		u, err := url.Parse(s)
 		if err != nil {
-			return nil, err
+			return url.URL{}, err
 		}
-
-		urlSlice = append(urlSlice, *uvalue)
-	}
-	return urlSlice, nil
+		return *u, nil
+	})
 }
 
 func parseTextUnmarshalers(field reflect.Value, data []string) error {
@@ -545,7 +551,7 @@ func parseTextUnmarshalers(field reflect.Value, data []string) error {
 		}
 		tm := sv.Interface().(encoding.TextUnmarshaler)
 		if err := tm.UnmarshalText([]byte(v)); err != nil {
-			return err
+			return fmt.Errorf("element %d (%q): %w", i, v, err)
 		}
 		if kind == reflect.Ptr {
 			slice.Index(i).Set(sv)
@@ -590,6 +596,8 @@ type VarInfo struct {
 	Type string
 	// HasDefault indicates if a default value is specified
 	HasDefault bool
+	// Description is the usage text of a variable declared in a Registry
+	Description string
 }
 
 // GetAllVars returns information about all environment variables that would be read
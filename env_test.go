@@ -340,7 +340,9 @@ func TestInvalidBoolsSlice(t *testing.T) {
 
 	os.Setenv("BADBOOLS", "t,f,TRUE,faaaalse")
 	cfg := &config{}
-	assert.Error(t, Parse(cfg))
+	err := Parse(cfg)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `element 3 ("faaaalse")`)
 }
 
 func TestInvalidDuration(t *testing.T) {
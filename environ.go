@@ -0,0 +1,116 @@
+package env
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+// Environ returns base with the variables from the given .env files added,
+// without changing the current process. base and the result use the
+// "KEY=value" form of os.Environ, so the result can be assigned to exec.Cmd.Env:
+//
+//	cmd := exec.Command("worker")
+//	cmd.Env, err = env.Environ(os.Environ(), ".env")
+//
+// Like Load, variables already present in base are NOT overridden, and the
+// first file to define a key wins. If no filenames are provided, it defaults
+// to reading ".env" from the current directory.
+func Environ(base []string, filenames ...string) ([]string, error) {
+	envMap, err := mergeFiles(readFile, filenames, false)
+	if err != nil {
+		return nil, err
+	}
+	return MergeEnviron(base, envMap, false), nil
+}
+
+// OverloadEnviron is like Environ, but the files override variables in base
+// and later files override earlier ones, as with Overload.
+func OverloadEnviron(base []string, filenames ...string) ([]string, error) {
+	envMap, err := mergeFiles(readFile, filenames, true)
+	if err != nil {
+		return nil, err
+	}
+	return MergeEnviron(base, envMap, true), nil
+}
+
+// MergeEnviron returns a copy of base with the variables in envMap merged in.
+// Entries of base keep their order; with overload their values are replaced by
+// those in envMap, otherwise they are kept. Variables that are not in base
+// are appended in sorted order. base is not modified.
+//
+//	environ := env.MergeEnviron(os.Environ(), envMap, true)
+func MergeEnviron(base []string, envMap map[string]string, overload bool) []string {
+	result := make([]string, 0, len(base)+len(envMap))
+	present := make(map[string]bool, len(base))
+	for _, entry := range base {
+		key, _, ok := splitEnviron(entry)
+		if ok {
+			if value, found := envMap[key]; found && overload {
+				entry = key + "=" + value
+			}
+			present[key] = true
+		}
+		result = append(result, entry)
+	}
+
+	added := make([]string, 0, len(envMap))
+	for key := range envMap {
+		if !present[key] {
+			added = append(added, key)
+		}
+	}
+	sort.Strings(added)
+	for _, key := range added {
+		result = append(result, key+"="+envMap[key])
+	}
+	return result
+}
+
+// FilterEnviron returns the entries of environ whose keys match at least one
+// of the allow patterns and none of the deny patterns. Patterns use the syntax
+// of path.Match, so "AWS_*" matches every key starting with AWS_. An empty
+// allow list allows every key.
+//
+// It is typically used to give a child process only what it needs:
+//
+//	cmd.Env, err = env.FilterEnviron(environ, []string{"PATH", "HOME", "APP_*"}, []string{"*_SECRET"})
+//
+// A malformed pattern is reported as path.ErrBadPattern.
+func FilterEnviron(environ []string, allow, deny []string) ([]string, error) {
+	for _, pattern := range append(append([]string(nil), allow...), deny...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	var result []string
+	for _, entry := range environ {
+		key, _, _ := splitEnviron(entry)
+		if (len(allow) == 0 || matchesAny(allow, key)) && !matchesAny(deny, key) {
+			result = append(result, entry)
+		}
+	}
+	return result, nil
+}
+
+// splitEnviron splits a "KEY=value" entry. The search for "=" starts after
+// the first character, since Windows has entries such as "=C:=C:\".
+func splitEnviron(entry string) (key, value string, ok bool) {
+	if entry == "" {
+		return "", "", false
+	}
+	if i := strings.Index(entry[1:], "="); i != -1 {
+		return entry[:i+1], entry[i+2:], true
+	}
+	return entry, "", false
+}
+
+func matchesAny(patterns []string, key string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package env
+
+import (
+	"path"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeEnviron(t *testing.T) {
+	base := []string{"PATH=/bin", "HOME=/root", "NOEQUALS", "=C:=C:\\"}
+	envMap := map[string]string{"HOME": "/home/app", "B": "2", "A": "1"}
+
+	assert.Equal(t, []string{"PATH=/bin", "HOME=/root", "NOEQUALS", "=C:=C:\\", "A=1", "B=2"},
+		MergeEnviron(base, envMap, false))
+	assert.Equal(t, []string{"PATH=/bin", "HOME=/home/app", "NOEQUALS", "=C:=C:\\", "A=1", "B=2"},
+		MergeEnviron(base, envMap, true))
+	assert.Equal(t, "HOME=/root", base[1], "base must not be modified")
+}
+
+func TestEnviron(t *testing.T) {
+	root := makeTree(t, map[string]string{
+		"base.env":  "SHARED=base\nHOME=/from/file\nONLY_BASE=1",
+		"local.env": "SHARED=local\nURL=http://${SHARED}",
+		"bad.env":   "INVALID LINE",
+	})
+	base, local := filepath.Join(root, "base.env"), filepath.Join(root, "local.env")
+	process := []string{"HOME=/root"}
+
+	environ, err := Environ(process, base, local)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"HOME=/root", "ONLY_BASE=1", "SHARED=base", "URL=http://local"}, environ)
+
+	environ, err = OverloadEnviron(process, base, local)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"HOME=/from/file", "ONLY_BASE=1", "SHARED=local", "URL=http://local"}, environ)
+
+	_, err = Environ(process, base, filepath.Join(root, "bad.env"))
+	assert.Error(t, err)
+}
+
+func TestFilterEnviron(t *testing.T) {
+	environ := []string{"PATH=/bin", "APP_NAME=x", "APP_SECRET=s", "AWS_KEY=k", "OTHER=o"}
+
+	filtered, err := FilterEnviron(environ, []string{"PATH", "APP_*"}, []string{"*_SECRET"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"PATH=/bin", "APP_NAME=x"}, filtered)
+
+	filtered, err = FilterEnviron(environ, nil, []string{"AWS_*", "APP_*"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"PATH=/bin", "OTHER=o"}, filtered)
+
+	_, err = FilterEnviron(environ, []string{"[bad"}, nil)
+	assert.ErrorIs(t, err, path.ErrBadPattern)
+}
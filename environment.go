@@ -0,0 +1,475 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// Environment is a set of environment variables that the Get, GetOr and
+// MustGet family of methods read from. It layers variables set with
+// Environment.Set over a list of sources, such as the process environment,
+// maps and .env files, consulted in order.
+//
+// The package-level functions such as Get and MustGetInt use the default
+// Environment, which reads and writes the process environment. Tests that use
+// their own Environment don't touch process state and can run with t.Parallel:
+//
+//	func TestHandler(t *testing.T) {
+//		t.Parallel()
+//		e := env.NewEnvironment(env.MapSource(map[string]string{"PORT": "8080"}))
+//		port := e.MustGetInt("PORT")
+//		...
+//	}
+//
+// An Environment is safe for concurrent use.
+type Environment struct {
+	mu      sync.RWMutex
+	values  map[string]string // set with Set
+	unset   map[string]bool   // removed with Unset, hiding the sources
+	sources []Lookuper
+	process bool // Set and Unset change the process environment
+
+	onInvalid func(err *ValueError)
+}
+
+var defaultEnvironment = &Environment{sources: []Lookuper{ProcessSource()}, process: true}
+
+// DefaultEnvironment returns the Environment used by the package-level
+// functions. It reads the process environment, and its Set and Unset methods
+// change the process environment.
+func DefaultEnvironment() *Environment {
+	return defaultEnvironment
+}
+
+// NewEnvironment returns an Environment that reads from sources, consulted in
+// order: the first source that has a variable provides its value. Set and
+// Unset only affect the returned Environment, never the sources or the
+// process:
+//
+//	files, err := env.FileSource(".env")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	e := env.NewEnvironment(env.ProcessSource(), files)
+func NewEnvironment(sources ...Lookuper) *Environment {
+	return &Environment{
+		values:  make(map[string]string),
+		unset:   make(map[string]bool),
+		sources: append([]Lookuper(nil), sources...),
+	}
+}
+
+// ProcessSource returns a source that reads the process environment.
+func ProcessSource() Lookuper {
+	return LookupFunc(os.LookupEnv)
+}
+
+// MapSource returns a source that reads a copy of envMap.
+func MapSource(envMap map[string]string) Lookuper {
+	copied := make(map[string]string, len(envMap))
+	for k, v := range envMap {
+		copied[k] = v
+	}
+	return LookupFunc(func(key string) (string, bool) {
+		value, ok := copied[key]
+		return value, ok
+	})
+}
+
+// FileSource reads the named .env files, with later files overriding earlier
+// ones as in Read, and returns a source holding their variables. The files are
+// read once; later changes to them are not seen.
+func FileSource(filenames ...string) (Lookuper, error) {
+	envMap, err := Read(filenames...)
+	if err != nil {
+		return nil, err
+	}
+	return MapSource(envMap), nil
+}
+
+// Lookup returns the value of key and whether it is set. It makes an
+// Environment usable as a source of another Environment.
+func (e *Environment) Lookup(key string) (string, bool) {
+	if !e.process {
+		e.mu.RLock()
+		value, set := e.values[key]
+		hidden := e.unset[key]
+		e.mu.RUnlock()
+		if set {
+			return value, true
+		}
+		if hidden {
+			return "", false
+		}
+	}
+
+	for _, source := range e.sources {
+		if value, ok := source.Lookup(key); ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// Set sets key to value in e.
+func (e *Environment) Set(key, value string) error {
+	if e.process {
+		return os.Setenv(key, value)
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.values[key] = value
+	delete(e.unset, key)
+	return nil
+}
+
+// Unset removes key from e, hiding any value its sources have.
+func (e *Environment) Unset(key string) error {
+	if e.process {
+		return os.Unsetenv(key)
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	delete(e.values, key)
+	e.unset[key] = true
+	return nil
+}
+
+// SetInvalidValueHandler sets the function called when a GetOr method of e
+// falls back to its default because the variable is set to a value that
+// can't be parsed. A nil fn restores the default, which uses the package-level
+// OnInvalidValue or DebugLogger.
+func (e *Environment) SetInvalidValueHandler(fn func(err *ValueError)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.onInvalid = fn
+}
+
+// reportInvalid reports a GetOr fallback caused by an invalid value.
+func (e *Environment) reportInvalid(err *ValueError) {
+	e.mu.RLock()
+	handler := e.onInvalid
+	e.mu.RUnlock()
+
+	switch {
+	case handler != nil:
+		handler(err)
+	case OnInvalidValue != nil:
+		OnInvalidValue(err)
+	case DebugLogger != nil:
+		DebugLogger("env: %v; using the default", err)
+	}
+}
+
+// typeName names T in ValueErrors, such as "int" or "time.Duration".
+func typeName[T any]() string {
+	var zero T
+	return fmt.Sprintf("%T", zero)
+}
+
+// GetParsedFrom is GetParsed for the Environment e.
+func GetParsedFrom[T any](e *Environment, key string, parser Parser[T]) (T, error) {
+	strValue, ok := e.Lookup(key)
+	if !ok {
+		var zero T
+		return zero, fmt.Errorf("%w: %s", ErrNotSet, key)
+	}
+	value, err := parser(strValue)
+	if err != nil {
+		return value, &ValueError{Key: key, Value: strValue, Type: typeName[T](), Err: err}
+	}
+	return value, nil
+}
+
+// GetOrParsedFrom is GetOrParsed for the Environment e.
+func GetOrParsedFrom[T any](e *Environment, key string, defaultValue T, parser Parser[T]) T {
+	strValue, ok := e.Lookup(key)
+	if !ok {
+		return defaultValue
+	}
+	value, err := parser(strValue)
+	if err != nil {
+		e.reportInvalid(&ValueError{Key: key, Value: strValue, Type: typeName[T](), Err: err})
+		return defaultValue
+	}
+	return value
+}
+
+// MustGetParsedFrom is MustGetParsed for the Environment e.
+func MustGetParsedFrom[T any](e *Environment, key string, parser Parser[T], typeName string) T {
+	strValue, ok := e.Lookup(key)
+	if ok {
+		if value, err := parser(strValue); err == nil {
+			return value
+		} else {
+			panic(fmt.Sprintf("environment variable \"%s\" could not be converted to %s", key, typeName))
+		}
+	}
+	panic(fmt.Sprintf("expected environment variable \"%s\" does not exist", key))
+}
+
+// GetSliceFrom is GetSlice for the Environment e.
+func GetSliceFrom[T any](e *Environment, key, sep string, parser Parser[T]) ([]T, error) {
+	return GetParsedFrom(e, key, SliceParser(sep, parser))
+}
+
+// GetOrSliceFrom is GetOrSlice for the Environment e.
+func GetOrSliceFrom[T any](e *Environment, key, sep string, defaultValue []T, parser Parser[T]) []T {
+	return GetOrParsedFrom(e, key, defaultValue, SliceParser(sep, parser))
+}
+
+// MustGetSliceFrom is MustGetSlice for the Environment e.
+func MustGetSliceFrom[T any](e *Environment, key, sep string, parser Parser[T], typeName string) []T {
+	values, err := GetSliceFrom(e, key, sep, parser)
+	var valueErr *ValueError
+	if errors.As(err, &valueErr) {
+		panic(fmt.Sprintf("environment variable \"%s\" could not be converted to []%s: %v", key, typeName, valueErr.Err))
+	}
+	if err != nil {
+		panic(fmt.Sprintf("expected environment variable \"%s\" does not exist", key))
+	}
+	return values
+}
+
+// GetMapFrom is GetMap for the Environment e.
+func GetMapFrom[K comparable, V any](e *Environment, key, sep, kvSep string, keyParser Parser[K], valueParser Parser[V]) (map[K]V, error) {
+	return GetParsedFrom(e, key, MapParser(sep, kvSep, keyParser, valueParser))
+}
+
+// Get returns the value of key, or an empty string if it is not set.
+func (e *Environment) Get(key string) string {
+	value, _ := e.Lookup(key)
+	return value
+}
+
+// GetOr returns the value of key, or defaultValue if it is not set.
+func (e *Environment) GetOr(key, defaultValue string) string {
+	if value, ok := e.Lookup(key); ok {
+		return value
+	}
+	return defaultValue
+}
+
+// MustGet returns the value of key, panicking if it is not set.
+func (e *Environment) MustGet(key string) string {
+	if value, ok := e.Lookup(key); ok {
+		return value
+	}
+	panic(fmt.Sprintf("expected environment variable \"%s\" does not exist", key))
+}
+
+// MustGetWithContext returns the value of key, panicking with a message that
+// includes context if it is not set.
+func (e *Environment) MustGetWithContext(key, context string) string {
+	if value, ok := e.Lookup(key); ok {
+		return value
+	}
+	panic(fmt.Sprintf("required env var %s missing (needed for: %s)", key, context))
+}
+
+// GetBool parses the value of key as a boolean.
+func (e *Environment) GetBool(key string) (bool, error) {
+	return GetParsedFrom(e, key, ParseBool)
+}
+
+// GetOrBool parses the value of key as a boolean, returning defaultValue if it
+// is not set or invalid.
+func (e *Environment) GetOrBool(key string, defaultValue bool) bool {
+	return GetOrParsedFrom(e, key, defaultValue, ParseBool)
+}
+
+// MustGetBool parses the value of key as a boolean, panicking if it is not set
+// or invalid.
+func (e *Environment) MustGetBool(key string) bool {
+	return MustGetParsedFrom(e, key, ParseBool, "bool")
+}
+
+// GetInt parses the value of key as an int.
+func (e *Environment) GetInt(key string) (int, error) {
+	return GetParsedFrom(e, key, ParseInt)
+}
+
+// GetOrInt parses the value of key as an int, returning defaultValue if it is
+// not set or invalid.
+func (e *Environment) GetOrInt(key string, defaultValue int) int {
+	return GetOrParsedFrom(e, key, defaultValue, ParseInt)
+}
+
+// MustGetInt parses the value of key as an int, panicking if it is not set or
+// invalid.
+func (e *Environment) MustGetInt(key string) int {
+	return MustGetParsedFrom(e, key, ParseInt, "int")
+}
+
+// GetUint parses the value of key as a uint.
+func (e *Environment) GetUint(key string) (uint, error) {
+	return GetParsedFrom(e, key, ParseUint)
+}
+
+// GetOrUint parses the value of key as a uint, returning defaultValue if it is
+// not set or invalid.
+func (e *Environment) GetOrUint(key string, defaultValue uint) uint {
+	return GetOrParsedFrom(e, key, defaultValue, ParseUint)
+}
+
+// MustGetUint parses the value of key as a uint, panicking if it is not set or
+// invalid.
+func (e *Environment) MustGetUint(key string) uint {
+	return MustGetParsedFrom(e, key, ParseUint, "uint")
+}
+
+// GetFloat32 parses the value of key as a float32.
+func (e *Environment) GetFloat32(key string) (float32, error) {
+	return GetParsedFrom(e, key, ParseFloat32)
+}
+
+// GetOrFloat32 parses the value of key as a float32, returning defaultValue if
+// it is not set or invalid.
+func (e *Environment) GetOrFloat32(key string, defaultValue float32) float32 {
+	return GetOrParsedFrom(e, key, defaultValue, ParseFloat32)
+}
+
+// MustGetFloat32 parses the value of key as a float32, panicking if it is not
+// set or invalid.
+func (e *Environment) MustGetFloat32(key string) float32 {
+	return MustGetParsedFrom(e, key, ParseFloat32, "float32")
+}
+
+// GetFloat64 parses the value of key as a float64.
+func (e *Environment) GetFloat64(key string) (float64, error) {
+	return GetParsedFrom(e, key, ParseFloat64)
+}
+
+// GetOrFloat64 parses the value of key as a float64, returning defaultValue if
+// it is not set or invalid.
+func (e *Environment) GetOrFloat64(key string, defaultValue float64) float64 {
+	return GetOrParsedFrom(e, key, defaultValue, ParseFloat64)
+}
+
+// MustGetFloat64 parses the value of key as a float64, panicking if it is not
+// set or invalid.
+func (e *Environment) MustGetFloat64(key string) float64 {
+	return MustGetParsedFrom(e, key, ParseFloat64, "float64")
+}
+
+// GetInt64 parses the value of key as an int64.
+func (e *Environment) GetInt64(key string) (int64, error) {
+	return GetParsedFrom(e, key, ParseInt64)
+}
+
+// GetOrInt64 parses the value of key as an int64, returning defaultValue if it
+// is not set or invalid.
+func (e *Environment) GetOrInt64(key string, defaultValue int64) int64 {
+	return GetOrParsedFrom(e, key, defaultValue, ParseInt64)
+}
+
+// MustGetInt64 parses the value of key as an int64, panicking if it is not set
+// or invalid.
+func (e *Environment) MustGetInt64(key string) int64 {
+	return MustGetParsedFrom(e, key, ParseInt64, "int64")
+}
+
+// GetUint64 parses the value of key as a uint64.
+func (e *Environment) GetUint64(key string) (uint64, error) {
+	return GetParsedFrom(e, key, ParseUint64)
+}
+
+// GetOrUint64 parses the value of key as a uint64, returning defaultValue if
+// it is not set or invalid.
+func (e *Environment) GetOrUint64(key string, defaultValue uint64) uint64 {
+	return GetOrParsedFrom(e, key, defaultValue, ParseUint64)
+}
+
+// MustGetUint64 parses the value of key as a uint64, panicking if it is not
+// set or invalid.
+func (e *Environment) MustGetUint64(key string) uint64 {
+	return MustGetParsedFrom(e, key, ParseUint64, "uint64")
+}
+
+// GetDuration parses the value of key as a time.Duration.
+func (e *Environment) GetDuration(key string) (time.Duration, error) {
+	return GetParsedFrom(e, key, ParseDuration)
+}
+
+// GetOrDuration parses the value of key as a time.Duration, returning the
+// parsed defaultValue if it is not set or invalid. It panics if defaultValue
+// is not a valid duration.
+func (e *Environment) GetOrDuration(key string, defaultValue string) time.Duration {
+	strValue, ok := e.Lookup(key)
+	if ok {
+		value, err := time.ParseDuration(strValue)
+		if err == nil {
+			return value
+		}
+		e.reportInvalid(&ValueError{Key: key, Value: strValue, Type: "time.Duration", Err: err})
+	}
+	defaultDuration, err := time.ParseDuration(defaultValue)
+	if err != nil {
+		panic(fmt.Sprintf("default duration \"%s\" could not be converted to time.Duration", defaultValue))
+	}
+	return defaultDuration
+}
+
+// MustGetDuration parses the value of key as a time.Duration, panicking if it
+// is not set or invalid.
+func (e *Environment) MustGetDuration(key string) time.Duration {
+	return MustGetParsedFrom(e, key, ParseDuration, "time.Duration")
+}
+
+// GetUrl parses the value of key as a URL.
+func (e *Environment) GetUrl(key string) (*url.URL, error) {
+	return GetParsedFrom(e, key, ParseURL)
+}
+
+// GetOrUrl parses the value of key as a URL, returning the parsed
+// defaultValue if it is not set or invalid. It panics if defaultValue is not
+// a valid URL.
+func (e *Environment) GetOrUrl(key string, defaultValue string) *url.URL {
+	strValue, ok := e.Lookup(key)
+	if ok {
+		value, err := url.ParseRequestURI(strValue)
+		if err == nil {
+			return value
+		}
+		e.reportInvalid(&ValueError{Key: key, Value: strValue, Type: "*url.URL", Err: err})
+	}
+	defaultUrl, err := url.ParseRequestURI(defaultValue)
+	if err != nil {
+		panic(fmt.Sprintf("default url \"%s\" could not be converted to url.URL", defaultValue))
+	}
+	return defaultUrl
+}
+
+// MustGetUrl parses the value of key as a URL, panicking if it is not set or
+// invalid.
+func (e *Environment) MustGetUrl(key string) *url.URL {
+	return MustGetParsedFrom(e, key, ParseURL, "url.URL")
+}
+
+// GetStrings splits the value of key on sep.
+func (e *Environment) GetStrings(key, sep string) ([]string, error) {
+	return GetParsedFrom(e, key, SliceParser(sep, ParseString))
+}
+
+// GetInts splits the value of key on sep and parses each element as an int.
+func (e *Environment) GetInts(key, sep string) ([]int, error) {
+	return GetParsedFrom(e, key, SliceParser(sep, ParseInt))
+}
+
+// GetDurations splits the value of key on sep and parses each element as a
+// time.Duration.
+func (e *Environment) GetDurations(key, sep string) ([]time.Duration, error) {
+	return GetParsedFrom(e, key, SliceParser(sep, ParseDuration))
+}
+
+// GetURLs splits the value of key on sep and parses each element as a URL.
+func (e *Environment) GetURLs(key, sep string) ([]*url.URL, error) {
+	return GetParsedFrom(e, key, SliceParser(sep, ParseURL))
+}
@@ -0,0 +1,211 @@
+package env
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvironmentSources(t *testing.T) {
+	t.Parallel()
+	e := NewEnvironment(
+		MapSource(map[string]string{"SRC_A": "first", "SRC_EMPTY": ""}),
+		MapSource(map[string]string{"SRC_A": "second", "SRC_B": "second"}),
+	)
+
+	assert.Equal(t, "first", e.Get("SRC_A"))
+	assert.Equal(t, "second", e.Get("SRC_B"))
+	assert.Equal(t, "", e.GetOr("SRC_EMPTY", "default"))
+	assert.Equal(t, "default", e.GetOr("SRC_MISSING", "default"))
+	assert.PanicsWithValue(t, `expected environment variable "SRC_MISSING" does not exist`, func() {
+		e.MustGet("SRC_MISSING")
+	})
+	assert.PanicsWithValue(t, "required env var SRC_MISSING missing (needed for: testing)", func() {
+		e.MustGetWithContext("SRC_MISSING", "testing")
+	})
+}
+
+func TestEnvironmentSetAndUnset(t *testing.T) {
+	t.Parallel()
+	source := map[string]string{"OVERLAY_KEY": "source"}
+	e := NewEnvironment(MapSource(source))
+
+	assert.NoError(t, e.Set("OVERLAY_KEY", "overlay"))
+	assert.Equal(t, "overlay", e.Get("OVERLAY_KEY"))
+
+	assert.NoError(t, e.Unset("OVERLAY_KEY"))
+	_, ok := e.Lookup("OVERLAY_KEY")
+	assert.False(t, ok, "Unset should hide the source value")
+
+	assert.NoError(t, e.Set("OVERLAY_KEY", "again"))
+	assert.Equal(t, "again", e.Get("OVERLAY_KEY"))
+
+	copied := NewEnvironment(MapSource(source))
+	source["OVERLAY_KEY"] = "changed"
+	assert.Equal(t, "source", copied.Get("OVERLAY_KEY"), "MapSource should copy its map")
+	_, set := os.LookupEnv("OVERLAY_KEY")
+	assert.False(t, set, "an isolated Environment must not change the process")
+}
+
+func TestEnvironmentTypedGetters(t *testing.T) {
+	t.Parallel()
+	e := NewEnvironment(MapSource(map[string]string{
+		"T_BOOL":     "true",
+		"T_INT":      "-42",
+		"T_UINT":     "42",
+		"T_FLOAT32":  "1.5",
+		"T_FLOAT64":  "2.5",
+		"T_INT64":    "-9000000000",
+		"T_UINT64":   "9000000000",
+		"T_DURATION": "90s",
+		"T_URL":      "https://example.com/path",
+		"T_BAD":      "not-a-value",
+	}))
+
+	b, err := e.GetBool("T_BOOL")
+	assert.NoError(t, err)
+	assert.True(t, b)
+	assert.Equal(t, -42, e.MustGetInt("T_INT"))
+	assert.Equal(t, uint(42), e.MustGetUint("T_UINT"))
+	assert.Equal(t, float32(1.5), e.MustGetFloat32("T_FLOAT32"))
+	assert.Equal(t, 2.5, e.MustGetFloat64("T_FLOAT64"))
+	assert.Equal(t, int64(-9000000000), e.MustGetInt64("T_INT64"))
+	assert.Equal(t, uint64(9000000000), e.MustGetUint64("T_UINT64"))
+	assert.Equal(t, 90*time.Second, e.MustGetDuration("T_DURATION"))
+	assert.Equal(t, "example.com", e.MustGetUrl("T_URL").Host)
+
+	assert.Equal(t, 7, e.GetOrInt("T_BAD", 7))
+	assert.Equal(t, 7, e.GetOrInt("T_MISSING", 7))
+	assert.Equal(t, time.Minute, e.GetOrDuration("T_BAD", "1m"))
+	assert.Equal(t, "fallback.example.com", e.GetOrUrl("T_BAD", "https://fallback.example.com").Host)
+	_, err = e.GetInt("T_BAD")
+	assert.Error(t, err)
+	assert.PanicsWithValue(t, `environment variable "T_BAD" could not be converted to int`, func() {
+		e.MustGetInt("T_BAD")
+	})
+
+	parsed, err := GetParsedFrom(e, "T_INT", ParseInt)
+	assert.NoError(t, err)
+	assert.Equal(t, -42, parsed)
+	assert.Equal(t, 3, GetOrParsedFrom(e, "T_MISSING", 3, ParseInt))
+	assert.Equal(t, int64(-9000000000), MustGetParsedFrom(e, "T_INT64", ParseInt64, "int64"))
+}
+
+func TestEnvironmentSlicesAndMaps(t *testing.T) {
+	t.Parallel()
+	e := NewEnvironment(MapSource(map[string]string{
+		"S_HOSTS":  "a;b",
+		"S_PORTS":  "80,443",
+		"S_BAD":    "80,x",
+		"S_LIMITS": "read:10,write:2",
+	}))
+
+	hosts, err := e.GetStrings("S_HOSTS", ";")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, hosts)
+	_, err = e.GetStrings("S_MISSING", ",")
+	assert.ErrorIs(t, err, ErrNotSet)
+
+	ports, err := GetSliceFrom(e, "S_PORTS", ",", ParseInt)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{80, 443}, ports)
+	assert.Equal(t, []int{80, 443}, MustGetSliceFrom(e, "S_PORTS", ",", ParseInt, "int"))
+	assert.Equal(t, []int{1}, GetOrSliceFrom(e, "S_BAD", ",", []int{1}, ParseInt))
+	assert.PanicsWithValue(t, `environment variable "S_BAD" could not be converted to []int: element 1 ("x"): strconv.ParseInt: parsing "x": invalid syntax`, func() {
+		MustGetSliceFrom(e, "S_BAD", ",", ParseInt, "int")
+	})
+	assert.PanicsWithValue(t, `expected environment variable "S_MISSING" does not exist`, func() {
+		MustGetSliceFrom(e, "S_MISSING", ",", ParseInt, "int")
+	})
+
+	limits, err := GetMapFrom(e, "S_LIMITS", "", "", ParseString, ParseInt)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"read": 10, "write": 2}, limits)
+	_, set := os.LookupEnv("S_PORTS")
+	assert.False(t, set)
+}
+
+func TestEnvironmentFileSource(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	filename := filepath.Join(dir, ".env")
+	assert.NoError(t, os.WriteFile(filename, []byte("FILE_SOURCE_PORT=8080\n"), 0o600))
+
+	files, err := FileSource(filename)
+	assert.NoError(t, err)
+	e := NewEnvironment(MapSource(map[string]string{"FILE_SOURCE_PORT": "9090"}), files)
+	assert.Equal(t, 9090, e.MustGetInt("FILE_SOURCE_PORT"))
+
+	e = NewEnvironment(files)
+	assert.Equal(t, 8080, e.MustGetInt("FILE_SOURCE_PORT"))
+
+	_, err = FileSource(filepath.Join(dir, "missing.env"))
+	assert.Error(t, err)
+}
+
+func TestEnvironmentAsSource(t *testing.T) {
+	t.Parallel()
+	base := NewEnvironment(MapSource(map[string]string{"LAYER_KEY": "base"}))
+	layered := NewEnvironment(base)
+	assert.Equal(t, "base", layered.Get("LAYER_KEY"))
+
+	assert.NoError(t, base.Set("LAYER_KEY", "changed"))
+	assert.Equal(t, "changed", layered.Get("LAYER_KEY"))
+}
+
+func TestEnvironmentConcurrentUse(t *testing.T) {
+	t.Parallel()
+	e := NewEnvironment()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := fmt.Sprintf("CONCURRENT_%d", i)
+			for j := 0; j < 100; j++ {
+				assert.NoError(t, e.Set(key, fmt.Sprint(j)))
+				_ = e.GetOrInt(key, 0)
+				assert.NoError(t, e.Unset(key))
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestDefaultEnvironment(t *testing.T) {
+	UnsetForTest(t, "DEFAULT_ENV_KEY")
+	e := DefaultEnvironment()
+
+	assert.NoError(t, e.Set("DEFAULT_ENV_KEY", "12"))
+	assert.Equal(t, "12", os.Getenv("DEFAULT_ENV_KEY"))
+	assert.Equal(t, 12, MustGetInt("DEFAULT_ENV_KEY"))
+
+	assert.NoError(t, os.Setenv("DEFAULT_ENV_KEY", "13"))
+	assert.Equal(t, 13, e.MustGetInt("DEFAULT_ENV_KEY"))
+
+	assert.NoError(t, e.Unset("DEFAULT_ENV_KEY"))
+	_, set := os.LookupEnv("DEFAULT_ENV_KEY")
+	assert.False(t, set)
+}
+
+func TestEnvironmentInvalidValueHandler(t *testing.T) {
+	t.Parallel()
+	e := NewEnvironment(MapSource(map[string]string{"HANDLER_PORT": "http"}))
+
+	var reported *ValueError
+	e.SetInvalidValueHandler(func(err *ValueError) { reported = err })
+	assert.Equal(t, 80, e.GetOrInt("HANDLER_PORT", 80))
+	if assert.NotNil(t, reported) {
+		assert.Equal(t, "HANDLER_PORT", reported.Key)
+		assert.Equal(t, "http", reported.Value)
+	}
+
+	_, err := e.GetUint("HANDLER_MISSING")
+	assert.ErrorIs(t, err, ErrNotSet)
+}
@@ -0,0 +1,210 @@
+package env
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// MarshalFormatOptions controls MarshalFormatWithOptions.
+type MarshalFormatOptions struct {
+	// Name is the metadata.name of FormatConfigMap and FormatSecret
+	// manifests. It defaults to "env".
+	Name string
+	// Namespace is the metadata.namespace of FormatConfigMap and
+	// FormatSecret manifests. It is omitted when empty.
+	Namespace string
+}
+
+var kubernetesKeyRegex = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+
+// MarshalFormat renders envMap in the syntax of another tool, with the
+// quoting and escaping that tool needs. Keys are sorted.
+//
+//	FormatDotenv     KEY="value", as Marshal
+//	FormatShell      export KEY='value'   (safe for eval in sh and bash)
+//	FormatFish       set -gx KEY 'value'
+//	FormatSystemd    KEY="value"          (for EnvironmentFile=)
+//	FormatDocker     KEY=value            (for docker run --env-file)
+//	FormatJSON       {"KEY": "value"}
+//	FormatConfigMap  a Kubernetes ConfigMap manifest
+//	FormatSecret     a Kubernetes Secret manifest
+//
+// Keys the target can't represent, such as "my-key" for a shell, and values
+// it can't represent, such as a newline for Docker, are reported as errors.
+// Other formats return ErrUnsupportedFormat.
+func MarshalFormat(envMap map[string]string, format Format) (string, error) {
+	return MarshalFormatWithOptions(envMap, format, MarshalFormatOptions{})
+}
+
+// MarshalFormatWithOptions is like MarshalFormat, but lets the caller name
+// the generated Kubernetes manifests:
+//
+//	manifest, err := env.MarshalFormatWithOptions(envMap, env.FormatSecret,
+//		env.MarshalFormatOptions{Name: "api-secrets", Namespace: "prod"})
+func MarshalFormatWithOptions(envMap map[string]string, format Format, opts MarshalFormatOptions) (string, error) {
+	keys := make([]string, 0, len(envMap))
+	for k := range envMap {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	switch format {
+	case FormatDotenv:
+		return Marshal(envMap)
+	case FormatJSON:
+		var sb strings.Builder
+		enc := json.NewEncoder(&sb)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		err := enc.Encode(envMap)
+		return strings.TrimSuffix(sb.String(), "\n"), err
+	case FormatShell, FormatFish, FormatSystemd, FormatDocker:
+		lines := make([]string, 0, len(keys))
+		for _, k := range keys {
+			line, err := marshalLine(k, envMap[k], format)
+			if err != nil {
+				return "", err
+			}
+			lines = append(lines, line)
+		}
+		return strings.Join(lines, "\n"), nil
+	case FormatConfigMap, FormatSecret:
+		return marshalKubernetes(envMap, keys, format, opts)
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+}
+
+func marshalLine(key, value string, format Format) (string, error) {
+	switch format {
+	case FormatShell, FormatFish:
+		if !shellKeyRegex.MatchString(key) {
+			return "", fmt.Errorf("key %q is not a valid %s variable name", key, format)
+		}
+		if format == FormatFish {
+			return "set -gx " + key + " " + fishQuote(value), nil
+		}
+		return "export " + key + "=" + shellQuote(value), nil
+	case FormatSystemd:
+		if !shellKeyRegex.MatchString(key) {
+			return "", fmt.Errorf("key %q is not a valid systemd variable name", key)
+		}
+		return key + "=" + systemdQuote(value), nil
+	default: // FormatDocker
+		if key == "" || strings.ContainsAny(key, "= \t\n\r") || strings.HasPrefix(key, "#") {
+			return "", fmt.Errorf("key %q can't be written to a docker env file", key)
+		}
+		if strings.ContainsAny(value, "\n\r") {
+			return "", fmt.Errorf("%s: docker env files can't hold values containing newlines", key)
+		}
+		return key + "=" + value, nil
+	}
+}
+
+// shellQuote single-quotes value for POSIX shells, where nothing inside single
+// quotes is special. A single quote closes the quoted string, adds an escaped
+// quote and opens a new one:
+//
+//	'\''
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// fishQuote single-quotes value for fish, where \' and \\ are the only
+// escapes inside single quotes.
+func fishQuote(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+// systemdQuote double-quotes value for an EnvironmentFile, escaping the
+// characters a backslash is significant for inside double quotes.
+func systemdQuote(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "`", "\\`").Replace(value) + `"`
+}
+
+func marshalKubernetes(envMap map[string]string, keys []string, format Format, opts MarshalFormatOptions) (string, error) {
+	name := opts.Name
+	if name == "" {
+		name = "env"
+	}
+
+	var sb strings.Builder
+	sb.WriteString("apiVersion: v1\n")
+	if format == FormatSecret {
+		sb.WriteString("kind: Secret\n")
+	} else {
+		sb.WriteString("kind: ConfigMap\n")
+	}
+	sb.WriteString("metadata:\n")
+	sb.WriteString("  name: " + yamlString(name) + "\n")
+	if opts.Namespace != "" {
+		sb.WriteString("  namespace: " + yamlString(opts.Namespace) + "\n")
+	}
+	if format == FormatSecret {
+		sb.WriteString("type: Opaque\n")
+	}
+	if len(keys) == 0 {
+		sb.WriteString("data: {}\n")
+		return sb.String(), nil
+	}
+
+	sb.WriteString("data:\n")
+	for _, k := range keys {
+		if !kubernetesKeyRegex.MatchString(k) {
+			return "", fmt.Errorf("key %q is not a valid %s key", k, format)
+		}
+		value := envMap[k]
+		if format == FormatSecret {
+			value = base64.StdEncoding.EncodeToString([]byte(value))
+		}
+		sb.WriteString("  " + k + ": " + yamlString(value) + "\n")
+	}
+	return sb.String(), nil
+}
+
+// yamlString quotes s as a YAML double-quoted scalar. JSON string syntax is a
+// subset of YAML's double-quoted style.
+func yamlString(s string) string {
+	var sb strings.Builder
+	enc := json.NewEncoder(&sb)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// parseDockerEnvFile reads a docker --env-file: values are taken literally,
+// lines starting with # are comments, and a line holding only a name passes
+// that variable through from the current environment if it is set.
+func parseDockerEnvFile(r io.Reader) (map[string]string, error) {
+	envMap := make(map[string]string)
+	lines := newLineReader(r, 0)
+	for {
+		line, err := lines.next()
+		if err == io.EOF {
+			return envMap, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line = strings.TrimLeft(line, " \t")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, hasValue := strings.Cut(line, "=")
+		if key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("line %d: invalid variable name %q", lines.line, key)
+		}
+		if !hasValue {
+			if value, hasValue = os.LookupEnv(key); !hasValue {
+				continue
+			}
+		}
+		envMap[key] = value
+	}
+}
@@ -0,0 +1,122 @@
+package env
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+var exportSample = map[string]string{
+	"PLAIN":   "value",
+	"QUOTES":  `it's "quoted"`,
+	"SHELLY":  "$HOME `whoami` $(id) \\n \\",
+	"SPACES":  "  padded  ",
+	"EMPTY":   "",
+	"UNICODE": "café <&>",
+}
+
+func TestMarshalFormatShell(t *testing.T) {
+	out, err := MarshalFormat(map[string]string{"A": "it's", "B": "$x"}, FormatShell)
+	assert.NoError(t, err)
+	assert.Equal(t, "export A='it'\\''s'\nexport B='$x'", out)
+
+	// the output is read back unchanged by a POSIX shell parser
+	withNewline := map[string]string{"MULTI": "one\ntwo"}
+	for k, v := range exportSample {
+		withNewline[k] = v
+	}
+	out, err = MarshalFormat(withNewline, FormatShell)
+	assert.NoError(t, err)
+	parsed, err := ParseFormat(strings.NewReader(out), FormatShell)
+	assert.NoError(t, err)
+	assert.Equal(t, withNewline, parsed)
+
+	_, err = MarshalFormat(map[string]string{"my-key": "x"}, FormatShell)
+	assert.Error(t, err)
+}
+
+func TestMarshalFormatFish(t *testing.T) {
+	out, err := MarshalFormat(map[string]string{"A": `it's a \ backslash`}, FormatFish)
+	assert.NoError(t, err)
+	assert.Equal(t, `set -gx A 'it\'s a \\ backslash'`, out)
+}
+
+func TestMarshalFormatSystemd(t *testing.T) {
+	out, err := MarshalFormat(map[string]string{"A": `say "hi" to $USER`}, FormatSystemd)
+	assert.NoError(t, err)
+	assert.Equal(t, `A="say \"hi\" to \$USER"`, out)
+
+	out, err = MarshalFormat(exportSample, FormatSystemd)
+	assert.NoError(t, err)
+	parsed, err := ParseFormat(strings.NewReader(out), FormatSystemd)
+	assert.NoError(t, err)
+	assert.Equal(t, exportSample, parsed)
+}
+
+func TestMarshalFormatDocker(t *testing.T) {
+	out, err := MarshalFormat(exportSample, FormatDocker)
+	assert.NoError(t, err)
+	assert.Contains(t, out, "QUOTES=it's \"quoted\"\n")
+
+	parsed, err := ParseFormat(strings.NewReader(out), FormatDocker)
+	assert.NoError(t, err)
+	assert.Equal(t, exportSample, parsed)
+
+	_, err = MarshalFormat(map[string]string{"A": "two\nlines"}, FormatDocker)
+	assert.Error(t, err)
+	_, err = MarshalFormat(map[string]string{"A B": "x"}, FormatDocker)
+	assert.Error(t, err)
+}
+
+func TestParseDockerEnvFile(t *testing.T) {
+	SetForTest(t, "DOCKER_PASSTHROUGH", "from-process")
+	UnsetForTest(t, "DOCKER_UNSET")
+
+	parsed, err := ParseFormat(strings.NewReader("# comment\n  A=\"not unquoted\" # kept\nDOCKER_PASSTHROUGH\nDOCKER_UNSET\n"), FormatDocker)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"A": `"not unquoted" # kept`, "DOCKER_PASSTHROUGH": "from-process"}, parsed)
+
+	_, err = ParseFormat(strings.NewReader("BAD KEY=1"), FormatDocker)
+	assert.EqualError(t, err, `line 1: invalid variable name "BAD KEY"`)
+}
+
+func TestMarshalFormatJSON(t *testing.T) {
+	out, err := MarshalFormat(map[string]string{"B": "<&>", "A": "1"}, FormatJSON)
+	assert.NoError(t, err)
+	assert.Equal(t, "{\n  \"A\": \"1\",\n  \"B\": \"<&>\"\n}", out)
+}
+
+func TestMarshalFormatKubernetes(t *testing.T) {
+	out, err := MarshalFormatWithOptions(exportSample, FormatConfigMap, MarshalFormatOptions{Name: "app", Namespace: "prod"})
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(out, "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: \"app\"\n  namespace: \"prod\"\ndata:\n"), out)
+
+	var configMap struct {
+		Kind     string
+		Metadata struct{ Name, Namespace string }
+		Data     map[string]string
+	}
+	assert.NoError(t, yaml.Unmarshal([]byte(out), &configMap))
+	assert.Equal(t, exportSample, configMap.Data)
+
+	out, err = MarshalFormat(map[string]string{"TOKEN": "s3cret"}, FormatSecret)
+	assert.NoError(t, err)
+	assert.Equal(t, "apiVersion: v1\nkind: Secret\nmetadata:\n  name: \"env\"\ntype: Opaque\ndata:\n  TOKEN: \"czNjcmV0\"\n", out)
+
+	out, err = MarshalFormat(nil, FormatConfigMap)
+	assert.NoError(t, err)
+	assert.Contains(t, out, "data: {}")
+
+	_, err = MarshalFormat(map[string]string{"bad key": "x"}, FormatConfigMap)
+	assert.Error(t, err)
+}
+
+func TestMarshalFormatUnsupported(t *testing.T) {
+	_, err := MarshalFormat(exportSample, FormatTOML)
+	assert.True(t, errors.Is(err, ErrUnsupportedFormat))
+	_, err = ParseFormat(strings.NewReader(""), FormatFish)
+	assert.True(t, errors.Is(err, ErrUnsupportedFormat))
+}
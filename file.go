@@ -1,11 +1,12 @@
 package env
 
 import (
-	"bufio"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
 	"regexp"
 	"sort"
 	"strings"
@@ -13,6 +14,10 @@ import (
 
 const doubleQuoteSpecialChars = "\\\n\r\"!$`"
 
+// defaultFilePerm is the mode of .env files created by Write and WriteFile.
+// They often hold secrets, so they are only readable by their owner.
+const defaultFilePerm os.FileMode = 0600
+
 // Load reads environment variables from .env files and sets them in the current process.
 // If no filenames are provided, it defaults to loading ".env" from the current directory.
 //
@@ -24,18 +29,15 @@ const doubleQuoteSpecialChars = "\\\n\r\"!$`"
 // This allows .env files to provide defaults while respecting existing environment configuration.
 // Use Overload if you need to override existing variables.
 //
-// The function returns an error if any file cannot be read or parsed.
+// The function returns an error if any file cannot be read or parsed. All files
+// are parsed before any variable is set, so on error the environment is unchanged.
 // Call this early in your program, typically in main().
+//
+// Files with a .json, .yaml, .yml, .toml, .ini or .properties extension are
+// parsed in that format and flattened into environment variable names; see
+// ParseFormat. Any other file is read as a .env file.
 func Load(filenames ...string) (err error) {
-	filenames = filenamesOrDefault(filenames)
-
-	for _, filename := range filenames {
-		err = loadFile(filename, false)
-		if err != nil {
-			return // return early on a spazout
-		}
-	}
-	return
+	return loadFiles(readFile, filenames, false)
 }
 
 // MustLoad reads environment variables from .env files and sets them in the current process.
@@ -50,13 +52,8 @@ func Load(filenames ...string) (err error) {
 //
 // Like Load, this will NOT override environment variables that are already set.
 func MustLoad(filenames ...string) {
-	filenames = filenamesOrDefault(filenames)
-
-	for _, filename := range filenames {
-		err := loadFile(filename, false)
-		if err != nil {
-			panic(err)
-		}
+	if err := loadFiles(readFile, filenames, false); err != nil {
+		panic(err)
 	}
 }
 
@@ -71,17 +68,14 @@ func MustLoad(filenames ...string) {
 //
 //	err := env.Overload("base.env", "production.env")
 //
-// The function returns an error if any file cannot be read or parsed.
+// The function returns an error if any file cannot be read or parsed. All files
+// are parsed before any variable is set, so on error the environment is unchanged.
+//
+// Files with a .json, .yaml, .yml, .toml, .ini or .properties extension are
+// parsed in that format and flattened into environment variable names; see
+// ParseFormat. Any other file is read as a .env file.
 func Overload(filenames ...string) (err error) {
-	filenames = filenamesOrDefault(filenames)
-
-	for _, filename := range filenames {
-		err = loadFile(filename, true)
-		if err != nil {
-			return // return early on a spazout
-		}
-	}
-	return
+	return loadFiles(readFile, filenames, true)
 }
 
 // MustOverload reads environment variables from .env files and sets them in the current process,
@@ -95,14 +89,67 @@ func Overload(filenames ...string) (err error) {
 //
 //	env.MustOverload("base.env", "production.env")
 func MustOverload(filenames ...string) {
-	filenames = filenamesOrDefault(filenames)
+	if err := loadFiles(readFile, filenames, true); err != nil {
+		panic(err)
+	}
+}
 
-	for _, filename := range filenames {
-		err := loadFile(filename, true)
-		if err != nil {
-			panic(err)
+// LoadEnvironment loads the conventional cascade of .env files for the given mode
+// (such as "development", "test" or "production"), as used by dotenv tooling
+// in Node and Rails. Files are listed here from highest to lowest precedence:
+//
+//	.env.<mode>.local   local overrides for this mode
+//	.env.local          local overrides for every mode (skipped when mode is "test")
+//	.env.<mode>         shared settings for this mode
+//	.env                shared defaults
+//
+// The mode specific files are skipped when mode is empty. Missing files are
+// ignored; the returned slice lists the files that were actually applied, in
+// the order they were loaded.
+//
+// Like Load, every file is parsed before any variable is set, so an invalid
+// file leaves the environment unchanged.
+//
+// Like Load, it will NOT override environment variables that are already set,
+// so values from the real environment take precedence over every file.
+func LoadEnvironment(mode string) (applied []string, err error) {
+	read := func(filename string) (map[string]string, error) {
+		envMap, err := readFile(filename)
+		if isMissingFile(err, filename) {
+			return nil, nil
+		}
+		if err == nil {
+			applied = append(applied, filename)
 		}
+		return envMap, err
+	}
+	if err = loadFiles(read, environmentFiles(mode), false); err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
+
+// isMissingFile reports whether err says that filename itself doesn't exist,
+// as opposed to a file it includes.
+func isMissingFile(err error, filename string) bool {
+	var pathErr *fs.PathError
+	return errors.As(err, &pathErr) && pathErr.Path == filename && errors.Is(err, fs.ErrNotExist)
+}
+
+// environmentFiles returns the files loaded by LoadEnvironment, highest
+// precedence first.
+func environmentFiles(mode string) []string {
+	var filenames []string
+	if mode != "" {
+		filenames = append(filenames, ".env."+mode+".local")
+	}
+	if mode != "test" {
+		filenames = append(filenames, ".env.local")
 	}
+	if mode != "" {
+		filenames = append(filenames, ".env."+mode)
+	}
+	return append(filenames, ".env")
 }
 
 // Read parses .env files and returns the key-value pairs as a map,
@@ -119,24 +166,12 @@ func MustOverload(filenames ...string) {
 //		log.Fatal(err)
 //	}
 //	fmt.Printf("DATABASE_URL=%s\n", envMap["DATABASE_URL"])
+//
+// Files with a .json, .yaml, .yml, .toml, .ini or .properties extension are
+// parsed in that format and flattened into environment variable names; see
+// ParseFormat. Any other file is read as a .env file.
 func Read(filenames ...string) (envMap map[string]string, err error) {
-	filenames = filenamesOrDefault(filenames)
-	envMap = make(map[string]string)
-
-	for _, filename := range filenames {
-		individualEnvMap, individualErr := readFile(filename)
-
-		if individualErr != nil {
-			err = individualErr
-			return // return early on a spazout
-		}
-
-		for key, value := range individualEnvMap {
-			envMap[key] = value
-		}
-	}
-
-	return
+	return readFiles(readFile, filenames)
 }
 
 // ParseIO reads environment variables from an io.Reader in .env format.
@@ -149,32 +184,165 @@ func Read(filenames ...string) (envMap map[string]string, err error) {
 //   - Multiline values
 //   - Both KEY=value and KEY: value formats
 //
+// ParseIO never reads other files: include directives ("# @include path" or
+// "source path") are only followed by Load, Read and the other file based
+// functions, or when ParseOptions.IncludeFS is set.
+//
 // It does not set any environment variables; it only parses and returns the data.
 func ParseIO(r io.Reader) (envMap map[string]string, err error) {
-	envMap = make(map[string]string)
+	return ParseIOWithOptions(r, ParseOptions{})
+}
 
-	var lines []string
-	scanner := bufio.NewScanner(r)
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
+// ErrLineTooLong is returned when a line exceeds ParseOptions.MaxLineLength.
+var ErrLineTooLong = errors.New("line too long")
+
+// ParseOptions controls how ParseIOWithOptions reads a .env file.
+type ParseOptions struct {
+	// MaxLineLength is the longest line, in bytes and excluding the line
+	// terminator, that will be accepted. Zero means no limit: lines are read
+	// in full however long they are, using memory proportional to the
+	// longest line rather than to the whole file.
+	MaxLineLength int
+
+	// Dialect selects the quoting, escaping, comment and expansion rules.
+	// The zero value, DialectGodotenv, is the behaviour of ParseIO.
+	Dialect Dialect
+
+	// IncludeFS, if not nil, enables include directives ("# @include path" or
+	// "source path"), which read another .env file from IncludeFS in place of
+	// the directive. Paths are resolved relative to the including file, or to
+	// the root of IncludeFS for the input itself. Includes may nest up to
+	// MaxIncludeDepth levels and must not form a cycle; failures are reported
+	// as an *IncludeError. When it is nil, "# @include" lines are comments.
+	IncludeFS fs.FS
+
+	// EncryptionKeys, if not nil, is used to look up EncryptionKeyVar, and
+	// encrypted values (enc:...) are decrypted with the key it returns. Values
+	// are left as written when it is nil or has no key; see EncryptValue.
+	EncryptionKeys Lookuper
+}
+
+// ParseIOWithOptions is like ParseIO, but lets the caller bound the length
+// of lines, for example when reading untrusted input:
+//
+//	envMap, err := env.ParseIOWithOptions(r, env.ParseOptions{MaxLineLength: 1 << 20})
+//	if errors.Is(err, env.ErrLineTooLong) {
+//		// reject the upload
+//	}
+//
+// or read a file written for another tool:
+//
+//	envMap, err := env.ParseIOWithOptions(r, env.ParseOptions{Dialect: env.DialectCompose})
+//
+// With DialectCompose and DialectPOSIX, variables the file doesn't define are
+// looked up in the process environment, as those tools do. Errors in other
+// dialects than DialectGodotenv include the line number.
+func ParseIOWithOptions(r io.Reader, opts ParseOptions) (envMap map[string]string, err error) {
+	var files includeFS
+	if opts.IncludeFS != nil {
+		files = fsIncludes{opts.IncludeFS}
 	}
+	return newDotenvParser(files).withOptions(opts).parse(r, "")
+}
 
-	if err = scanner.Err(); err != nil {
-		return
+// dotenvParser parses .env files, following include directives through files
+// unless it is nil, and decrypting "enc:" values with the key found through
+// opts.EncryptionKeys.
+type dotenvParser struct {
+	files includeFS
+	opts  ParseOptions
+	chain []string // files being parsed, outermost first
+	depth int      // number of include directives being followed
+}
+
+func newDotenvParser(files includeFS) *dotenvParser {
+	return &dotenvParser{files: files}
+}
+
+func (p *dotenvParser) withOptions(opts ParseOptions) *dotenvParser {
+	p.opts = opts
+	return p
+}
+
+// parse reads a complete .env file. The name is used to resolve include
+// directives and may be empty for input that doesn't come from a file.
+func (p *dotenvParser) parse(r io.Reader, name string) (map[string]string, error) {
+	if name != "" && p.files != nil {
+		p.chain = []string{p.files.Resolve("", name)}
+	}
+	envMap := make(map[string]string)
+	if err := p.parseInto(r, envMap); err != nil {
+		return nil, err
 	}
+	return envMap, nil
+}
 
-	for _, fullLine := range lines {
-		if !isIgnoredLine(fullLine) {
-			var key, value string
-			key, value, err = parseLine(fullLine, envMap)
+func (p *dotenvParser) parseInto(r io.Reader, envMap map[string]string) error {
+	lines := newLineReader(r, p.opts.MaxLineLength)
+	for {
+		fullLine, err := lines.next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
 
+		if target, ok := includeTarget(fullLine); ok && p.files != nil {
+			if err = p.include(target, envMap); err != nil {
+				return err
+			}
+			continue
+		}
+		if p.opts.Dialect != DialectGodotenv {
+			if err = p.parseDialectLine(lines, fullLine, envMap); err != nil {
+				return err
+			}
+			continue
+		}
+		if !isIgnoredLine(fullLine) {
+			key, value, err := parseLine(fullLine, envMap)
 			if err != nil {
-				return
+				return err
+			}
+			value, err = decryptParsedValue(key, value, p.opts.EncryptionKeys)
+			if err != nil {
+				return err
 			}
 			envMap[key] = value
 		}
 	}
-	return
+}
+
+// parseDialectLine parses an entry starting at line with the rules of
+// p.opts.Dialect, reading further lines while the value is incomplete.
+func (p *dotenvParser) parseDialectLine(lines *lineReader, line string, envMap map[string]string) error {
+	if isDialectIgnoredLine(line, p.opts.Dialect) {
+		return nil
+	}
+	first := lines.line
+	for {
+		key, value, err := parseDialectEntry(line, p.opts.Dialect, envMap)
+		if err == errIncompleteValue {
+			next, nextErr := lines.next()
+			if nextErr == io.EOF {
+				return fmt.Errorf("line %d: %w", first, err)
+			}
+			if nextErr != nil {
+				return nextErr
+			}
+			line += "\n" + next
+			continue
+		}
+		if err == nil {
+			value, err = decryptParsedValue(key, value, p.opts.EncryptionKeys)
+		}
+		if err != nil {
+			return fmt.Errorf("line %d: %w", first, err)
+		}
+		envMap[key] = value
+		return nil
+	}
 }
 
 // Unmarshal parses environment variables from a string in .env format.
@@ -190,18 +358,39 @@ func Unmarshal(str string) (envMap map[string]string, err error) {
 // The output file will contain KEY="VALUE" pairs, one per line,
 // with keys sorted alphabetically and values properly escaped.
 //
-// This function will create or overwrite the specified file.
+// This function will create or overwrite the specified file. The file is
+// replaced atomically and flushed to disk; see WriteFile for details and for
+// control over permissions and backups.
 func Write(envMap map[string]string, filename string) error {
-	content, error := Marshal(envMap)
-	if error != nil {
-		return error
-	}
-	file, error := os.Create(filename)
-	if error != nil {
-		return error
+	return WriteFile(envMap, filename, WriteOptions{Fsync: true})
+}
+
+// WriteOptions configures WriteFile.
+type WriteOptions struct {
+	// Perm is the permission of the written file. If zero, the permissions of
+	// an existing file are kept and new files are created with mode 0600.
+	Perm os.FileMode
+	// Backup keeps a copy of the previous contents at filename + ".bak".
+	Backup bool
+	// Fsync flushes the file and its directory to stable storage before
+	// WriteFile returns.
+	Fsync bool
+}
+
+// WriteFile serializes a map of environment variables to a .env file, like Write,
+// without ever leaving a partially written file behind.
+//
+// The content is written to a temporary file in the same directory, which is
+// then renamed over filename. Readers see either the old file or the new one;
+// if the process dies mid-write, the original file is untouched. Because the
+// target is replaced by a rename, a symlink at filename is replaced rather
+// than followed.
+func WriteFile(envMap map[string]string, filename string, opts WriteOptions) error {
+	content, err := Marshal(envMap)
+	if err != nil {
+		return err
 	}
-	_, err := file.WriteString(content)
-	return err
+	return writeFileAtomic(filename, []byte(content), opts)
 }
 
 // Marshal converts a map of environment variables to .env file format.
@@ -218,6 +407,190 @@ func Marshal(envMap map[string]string) (string, error) {
 	return strings.Join(lines, "\n"), nil
 }
 
+// QuoteStyle controls how MarshalWithOptions quotes values.
+type QuoteStyle int
+
+const (
+	// QuoteMinimal writes values bare when that is safe, in single quotes when
+	// the value can be taken literally, and in double quotes with backslash
+	// escapes otherwise. See QuoteValue.
+	QuoteMinimal QuoteStyle = iota
+	// QuoteDouble wraps every value in double quotes, as Marshal does.
+	QuoteDouble
+)
+
+// MarshalOptions configures MarshalWithOptions.
+type MarshalOptions struct {
+	// Quote selects the quoting policy. The zero value is QuoteMinimal.
+	Quote QuoteStyle
+	// Order lists keys to write first, in the given order, for example the
+	// result of Document.Keys. Keys missing from Order follow, sorted
+	// alphabetically; keys in Order that are not in the map are ignored.
+	Order []string
+}
+
+// MarshalWithOptions converts a map of environment variables to .env file format
+// using the quoting policy and key order from opts.
+//
+// The output is guaranteed to round-trip: for any map whose keys are valid,
+// Unmarshal(MarshalWithOptions(m, opts)) returns a map equal to m, whatever the
+// values contain (quotes, hashes, dollar signs, backslashes, newlines or
+// surrounding whitespace). Keys that ParseIO could not read back, such as empty
+// keys or keys containing whitespace, '=', ':', '#' or quotes, are reported as
+// an error.
+func MarshalWithOptions(envMap map[string]string, opts MarshalOptions) (string, error) {
+	keys := make([]string, 0, len(envMap))
+	seen := make(map[string]bool, len(envMap))
+	for _, k := range opts.Order {
+		if _, ok := envMap[k]; ok && !seen[k] {
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
+	var rest []string
+	for k := range envMap {
+		if !seen[k] {
+			rest = append(rest, k)
+		}
+	}
+	sort.Strings(rest)
+	keys = append(keys, rest...)
+
+	lines := make([]string, 0, len(keys))
+	for _, k := range keys {
+		if !isWritableKey(k) {
+			return "", fmt.Errorf("key %q cannot be written to a .env file", k)
+		}
+		v := envMap[k]
+		if opts.Quote == QuoteDouble {
+			v = `"` + doubleQuoteEscape(v) + `"`
+		} else {
+			v = QuoteValue(v)
+		}
+		lines = append(lines, k+"="+v)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// QuoteValue returns value formatted for the right-hand side of a .env line
+// using the minimal quoting that ParseIO reads back unchanged:
+//   - bare, if the value is empty or only contains letters, digits and _-.,/:@%+=~^
+//   - single quoted, if the value contains no single quote or line break
+//   - double quoted otherwise, escaping backslashes, double quotes, dollar signs,
+//     newlines and carriage returns
+func QuoteValue(value string) string {
+	if strings.IndexFunc(value, isUnsafeBareRune) == -1 {
+		return value
+	}
+	if !strings.ContainsAny(value, "'\n\r") {
+		return "'" + value + "'"
+	}
+
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for _, c := range value {
+		switch c {
+		case '\\', '"', '$':
+			sb.WriteByte('\\')
+			sb.WriteRune(c)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		default:
+			sb.WriteRune(c)
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+func isUnsafeBareRune(c rune) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		return false
+	case strings.ContainsRune("_-.,/:@%+=~^", c):
+		return false
+	}
+	return true
+}
+
+// isWritableKey reports whether ParseIO would read key back unchanged.
+func isWritableKey(key string) bool {
+	return key != "" && !strings.ContainsAny(key, " \t\n\r\v\f=:#\"'")
+}
+
+func writeFileAtomic(filename string, data []byte, opts WriteOptions) (err error) {
+	perm := opts.Perm
+	info, statErr := os.Stat(filename)
+	if perm == 0 {
+		perm = defaultFilePerm
+		if statErr == nil {
+			perm = info.Mode().Perm()
+		}
+	}
+
+	if opts.Backup && statErr == nil {
+		previous, err := os.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+		backupOpts := WriteOptions{Perm: info.Mode().Perm(), Fsync: opts.Fsync}
+		if err := writeFileAtomic(filename+".bak", previous, backupOpts); err != nil {
+			return err
+		}
+	}
+
+	dir, base := filepath.Split(filename)
+	if dir == "" {
+		dir = "."
+	}
+	tmp, err := os.CreateTemp(dir, "."+base+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if err = tmp.Chmod(perm); err != nil {
+		return err
+	}
+	if _, err = tmp.Write(data); err != nil {
+		return err
+	}
+	if opts.Fsync {
+		if err = tmp.Sync(); err != nil {
+			return err
+		}
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), filename); err != nil {
+		return err
+	}
+	if opts.Fsync {
+		return syncDir(dir)
+	}
+	return nil
+}
+
+// syncDir flushes a directory entry change (such as a rename) to disk.
+// Not every platform supports syncing a directory, so failures are ignored.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	_ = d.Sync()
+	return nil
+}
+
 func filenamesOrDefault(filenames []string) []string {
 	if len(filenames) == 0 {
 		return []string{".env"}
@@ -225,29 +598,97 @@ func filenamesOrDefault(filenames []string) []string {
 	return filenames
 }
 
-func loadFile(filename string, overload bool) error {
-	envMap, err := readFile(filename)
+// fileReader reads and parses a single .env file. It lets the loading logic be
+// shared between the operating system's filesystem and an fs.FS.
+type fileReader func(filename string) (map[string]string, error)
+
+func loadFiles(read fileReader, filenames []string, overload bool) error {
+	_, err := applyFiles(read, filenames, overload)
+	return err
+}
+
+// applyFiles reads every file before touching the environment, so a file that
+// can't be read or parsed leaves the process unchanged.
+func applyFiles(read fileReader, filenames []string, overload bool) (*Restorer, error) {
+	merged, sources, err := mergeFileSources(read, filenames, overload)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return applyEnvMap(merged, sources, overload)
+}
 
-	for key, value := range envMap {
-		if _, exists := os.LookupEnv(key); !exists || overload {
-			os.Setenv(key, value)
+// mergeFiles reads every file into a single map with Load (first file wins)
+// or Overload (last file wins) precedence.
+func mergeFiles(read fileReader, filenames []string, overload bool) (map[string]string, error) {
+	merged, _, err := mergeFileSources(read, filenames, overload)
+	return merged, err
+}
+
+// mergeFileSources is mergeFiles, also returning the file each value came from.
+func mergeFileSources(read fileReader, filenames []string, overload bool) (merged, sources map[string]string, err error) {
+	merged = make(map[string]string)
+	sources = make(map[string]string)
+	for _, filename := range filenamesOrDefault(filenames) {
+		envMap, err := read(filename)
+		if err != nil {
+			return nil, nil, err // return early on a spazout
+		}
+		for key, value := range envMap {
+			if _, seen := merged[key]; !seen || overload {
+				merged[key] = value
+				sources[key] = filename
+			}
 		}
 	}
+	return merged, sources, nil
+}
 
-	return nil
+func readFiles(read fileReader, filenames []string) (map[string]string, error) {
+	envMap := make(map[string]string)
+	for _, filename := range filenamesOrDefault(filenames) {
+		individualEnvMap, err := read(filename)
+		if err != nil {
+			return envMap, err // return early on a spazout
+		}
+
+		for key, value := range individualEnvMap {
+			envMap[key] = value
+		}
+	}
+	return envMap, nil
 }
 
-func readFile(filename string) (envMap map[string]string, err error) {
-	file, err := os.Open(filename)
-	if err != nil {
-		return
+func loadFile(filename string, overload bool) error {
+	return loadFiles(readFile, []string{filename}, overload)
+}
+
+// applyEnvMap sets the variables in envMap, recording their previous state
+// and the file named in sources that set them. If any variable can't be set,
+// the ones already set are restored.
+func applyEnvMap(envMap, sources map[string]string, overload bool) (*Restorer, error) {
+	keys := make([]string, 0, len(envMap))
+	for key := range envMap {
+		keys = append(keys, key)
 	}
-	defer file.Close()
+	sort.Strings(keys)
 
-	return ParseIO(file)
+	r := &Restorer{}
+	for _, key := range keys {
+		previous, exists := os.LookupEnv(key)
+		if exists && !overload {
+			continue
+		}
+		if err := os.Setenv(key, envMap[key]); err != nil {
+			_ = r.Restore()
+			return nil, fmt.Errorf("setting %s: %w", key, err)
+		}
+		r.changes = append(r.changes, envChange{key: key, value: previous, existed: exists, source: setSource(key, sources[key], envMap[key])})
+	}
+	return r, nil
+}
+
+func readFile(filename string) (envMap map[string]string, err error) {
+	return readFileFormat(filename, FormatAuto)
 }
 
 func parseLine(line string, envMap map[string]string) (key string, value string, err error) {
@@ -257,48 +698,63 @@ func parseLine(line string, envMap map[string]string) (key string, value string,
 	}
 
 	// ditch the comments (but keep quoted hashes)
-	if strings.Contains(line, "#") {
-		segmentsBetweenHashes := strings.Split(line, "#")
-		quotesAreOpen := false
-		var segmentsToKeep []string
-		for _, segment := range segmentsBetweenHashes {
-			if strings.Count(segment, "\"") == 1 || strings.Count(segment, "'") == 1 {
-				if quotesAreOpen {
-					quotesAreOpen = false
-					segmentsToKeep = append(segmentsToKeep, segment)
-				} else {
-					quotesAreOpen = true
-				}
-			}
+	line = stripComment(line)
+
+	sep := separatorIndex(line)
+	if sep == -1 {
+		err = errors.New("can't separate key from value")
+		return
+	}
+
+	// Parse the key
+	key = parseKey(line[:sep])
+
+	// Parse the value
+	value = parseValue(line[sep+1:], envMap)
+	return
+}
 
-			if len(segmentsToKeep) == 0 || quotesAreOpen {
-				segmentsToKeep = append(segmentsToKeep, segment)
+// stripComment removes a trailing comment from a line. A hash only starts a
+// comment when it appears outside of single or double quotes; inside double
+// quotes a backslash escapes the following character.
+func stripComment(line string) string {
+	var quote rune
+	escaped := false
+	for i, c := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && c == '\\':
+			escaped = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
 			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return line[:i]
 		}
-
-		line = strings.Join(segmentsToKeep, "#")
 	}
+	return line
+}
 
+// separatorIndex returns the position of the character separating the key
+// from the value, or -1 if there is none. A colon only acts as the separator
+// (yaml-style) when it comes before the first equals sign.
+func separatorIndex(line string) int {
 	firstEquals := strings.Index(line, "=")
 	firstColon := strings.Index(line, ":")
-	splitString := strings.SplitN(line, "=", 2)
 	if firstColon != -1 && (firstColon < firstEquals || firstEquals == -1) {
 		//this is a yaml-style line
-		splitString = strings.SplitN(line, ":", 2)
-	}
-
-	if len(splitString) != 2 {
-		err = errors.New("can't separate key from value")
-		return
+		return firstColon
 	}
+	return firstEquals
+}
 
-	// Parse the key
+func parseKey(key string) string {
 	re := regexp.MustCompile(`^\s*(?:export\s+)?(.*?)\s*$`)
-	key = re.ReplaceAllString(splitString[0], "$1")
-
-	// Parse the value
-	value = parseValue(splitString[1], envMap)
-	return
+	return re.ReplaceAllString(key, "$1")
 }
 
 func parseValue(value string, envMap map[string]string) string {
@@ -3,11 +3,15 @@ package env
 import (
 	"bytes"
 	"fmt"
+	"math/rand"
 	"os"
+	"path/filepath"
 	"reflect"
+	"runtime"
 	"strconv"
 	"strings"
 	"testing"
+	"testing/quick"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -478,6 +482,216 @@ func TestRoundtrip(t *testing.T) {
 	}
 }
 
+func TestQuoteValue(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"", ""},
+		{"value", "value"},
+		{"postgres://user@localhost:5432/db?sslmode=disable", "'postgres://user@localhost:5432/db?sslmode=disable'"},
+		{"a=b,c/d:e", "a=b,c/d:e"},
+		{"with spaces", "'with spaces'"},
+		{" leading", "' leading'"},
+		{"bar#baz", "'bar#baz'"},
+		{`say "hi"`, `'say "hi"'`},
+		{"$HOME", "'$HOME'"},
+		{`back\slash`, `'back\slash'`},
+		{"it's", `"it's"`},
+		{"it's $HOME", `"it's \$HOME"`},
+		{"line\nbreak", `"line\nbreak"`},
+		{"cr\r", `"cr\r"`},
+		{`it's "\"`, `"it's \"\\\""`},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, QuoteValue(tt.value), tt.value)
+	}
+}
+
+func TestMarshalWithOptions(t *testing.T) {
+	envMap := map[string]string{
+		"B":    "2",
+		"A":    "1",
+		"C":    "three four",
+		"ZETA": "it's",
+	}
+
+	content, err := MarshalWithOptions(envMap, MarshalOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "A=1\nB=2\nC='three four'\nZETA=\"it's\"", content)
+
+	content, err = MarshalWithOptions(envMap, MarshalOptions{Order: []string{"ZETA", "B", "MISSING", "B"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "ZETA=\"it's\"\nB=2\nA=1\nC='three four'", content)
+
+	content, err = MarshalWithOptions(envMap, MarshalOptions{Quote: QuoteDouble})
+	assert.NoError(t, err)
+	assert.Equal(t, "A=\"1\"\nB=\"2\"\nC=\"three four\"\nZETA=\"it's\"", content)
+
+	for _, key := range []string{"", "MY KEY", "A=B", "A:B", "#A", "A'"} {
+		_, err = MarshalWithOptions(map[string]string{key: "value"}, MarshalOptions{})
+		assert.Error(t, err, key)
+	}
+}
+
+// roundtripAlphabet is weighted towards characters that are significant to
+// the .env grammar.
+var roundtripAlphabet = []rune("aZ09_ -.,/:@%+=~^#'\"$\\{}()`!\n\r\té世")
+
+type roundtripValue string
+
+func (roundtripValue) Generate(r *rand.Rand, size int) reflect.Value {
+	runes := make([]rune, r.Intn(size+1))
+	for i := range runes {
+		runes[i] = roundtripAlphabet[r.Intn(len(roundtripAlphabet))]
+	}
+	return reflect.ValueOf(roundtripValue(runes))
+}
+
+func TestMarshalRoundtripProperty(t *testing.T) {
+	for _, quote := range []QuoteStyle{QuoteMinimal, QuoteDouble} {
+		roundtrips := func(a, b, c roundtripValue) bool {
+			envMap := map[string]string{"A": string(a), "B_B": string(b), "c.c": string(c)}
+			content, err := MarshalWithOptions(envMap, MarshalOptions{Quote: quote})
+			if err != nil {
+				return false
+			}
+			parsed, err := ParseIO(strings.NewReader(content))
+			return err == nil && reflect.DeepEqual(envMap, parsed)
+		}
+		if err := quick.Check(roundtrips, &quick.Config{MaxCount: 2000}); err != nil {
+			t.Errorf("quote style %d: %v", quote, err)
+		}
+	}
+}
+
+func TestInlineCommentsRespectQuotes(t *testing.T) {
+	parseAndCompare(t, "FOO=bar # it's a comment", "FOO", "bar")
+	parseAndCompare(t, `FOO="a#b'c#d" # comment`, "FOO", "a#b'c#d")
+	parseAndCompare(t, `FOO="a\"#b" # comment`, "FOO", `a"#b`)
+	parseAndCompare(t, `FOO='a"#b' # comment`, "FOO", `a"#b`)
+}
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "secrets.env")
+
+	// new files default to owner-only permissions
+	assert.NoError(t, Write(map[string]string{"A": "1"}, filename))
+	info, err := os.Stat(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// existing permissions are preserved
+	assert.NoError(t, os.Chmod(filename, 0640))
+	assert.NoError(t, WriteFile(map[string]string{"A": "2"}, filename, WriteOptions{}))
+	info, err = os.Stat(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+
+	// explicit permissions win
+	assert.NoError(t, WriteFile(map[string]string{"A": "3"}, filename, WriteOptions{Perm: 0644, Fsync: true}))
+	info, err = os.Stat(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0644), info.Mode().Perm())
+
+	envMap, err := Read(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"A": "3"}, envMap)
+
+	// no temporary files are left behind
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestWriteFileBackup(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, ".env")
+
+	// nothing to back up yet
+	assert.NoError(t, WriteFile(map[string]string{"A": "1"}, filename, WriteOptions{Backup: true}))
+	_, err := os.Stat(filename + ".bak")
+	assert.True(t, os.IsNotExist(err))
+
+	assert.NoError(t, WriteFile(map[string]string{"A": "2"}, filename, WriteOptions{Backup: true}))
+	backup, err := Read(filename + ".bak")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"A": "1"}, backup)
+	current, err := Read(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"A": "2"}, current)
+
+	info, err := os.Stat(filename + ".bak")
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestWriteFileMissingDirectory(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "missing", ".env")
+	assert.Error(t, Write(map[string]string{"A": "1"}, filename))
+}
+
+func TestLoadEnvironment(t *testing.T) {
+	root := makeTree(t, map[string]string{
+		".env":                   "CASCADE_A=env\nCASCADE_B=env\nCASCADE_C=env\nCASCADE_D=env\nCASCADE_E=env",
+		".env.development":       "CASCADE_A=development\nCASCADE_B=development\nCASCADE_C=development",
+		".env.local":             "CASCADE_A=local\nCASCADE_B=local",
+		".env.development.local": "CASCADE_A=development.local",
+		".env.test":              "CASCADE_B=test",
+	})
+	chdir(t, root)
+	for _, key := range []string{"CASCADE_A", "CASCADE_B", "CASCADE_C", "CASCADE_D", "CASCADE_E"} {
+		UnsetForTest(t, key)
+	}
+	SetForTest(t, "CASCADE_E", "process")
+
+	applied, err := LoadEnvironment("development")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{".env.development.local", ".env.local", ".env.development", ".env"}, applied)
+	assert.Equal(t, "development.local", os.Getenv("CASCADE_A"))
+	assert.Equal(t, "local", os.Getenv("CASCADE_B"))
+	assert.Equal(t, "development", os.Getenv("CASCADE_C"))
+	assert.Equal(t, "env", os.Getenv("CASCADE_D"))
+	assert.Equal(t, "process", os.Getenv("CASCADE_E"))
+}
+
+func TestLoadEnvironmentTestModeSkipsLocal(t *testing.T) {
+	root := makeTree(t, map[string]string{
+		".env":       "CASCADE_A=env",
+		".env.local": "CASCADE_A=local",
+		".env.test":  "CASCADE_B=test",
+	})
+	chdir(t, root)
+	UnsetForTest(t, "CASCADE_A")
+	UnsetForTest(t, "CASCADE_B")
+
+	applied, err := LoadEnvironment("test")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{".env.test", ".env"}, applied)
+	assert.Equal(t, "env", os.Getenv("CASCADE_A"))
+	assert.Equal(t, "test", os.Getenv("CASCADE_B"))
+}
+
+func TestLoadEnvironmentErrors(t *testing.T) {
+	root := makeTree(t, map[string]string{
+		".env.local": "CASCADE_A=local",
+		".env":       "INVALID LINE",
+	})
+	chdir(t, root)
+	UnsetForTest(t, "CASCADE_A")
+
+	// nothing is applied when any file in the cascade is invalid
+	applied, err := LoadEnvironment("")
+	assert.Error(t, err)
+	assert.Empty(t, applied)
+	_, set := os.LookupEnv("CASCADE_A")
+	assert.False(t, set)
+
+	assert.Equal(t, []string{".env.local", ".env"}, environmentFiles(""))
+}
+
 // Benchmark tests for performance improvements
 func BenchmarkLoadFile(b *testing.B) {
 	// Create a temporary .env file for benchmarking
@@ -561,9 +775,32 @@ func BenchmarkParseUint64s(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		_, err := parseUint64s(testData)
+		_, err := parseUint64s(testData, NumberFormat{})
 		if err != nil {
 			b.Fatalf("parseUint64s failed: %v", err)
 		}
 	}
 }
+
+// BenchmarkParseIOLongValue shows that the memory used to parse a file grows
+// with the size of its largest value: alloc-bytes/value-byte stays roughly
+// constant as the value grows.
+func BenchmarkParseIOLongValue(b *testing.B) {
+	for _, size := range []int{64 << 10, 1 << 20, 8 << 20} {
+		content := "BEFORE=1\nKEYSTORE=\"" + strings.Repeat("QUJD", size/4) + "\"\nAFTER=2\n"
+		b.Run(strconv.Itoa(size>>10)+"KiB", func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(content)))
+
+			var before, after runtime.MemStats
+			runtime.ReadMemStats(&before)
+			for i := 0; i < b.N; i++ {
+				if _, err := ParseIO(strings.NewReader(content)); err != nil {
+					b.Fatalf("ParseIO failed: %v", err)
+				}
+			}
+			runtime.ReadMemStats(&after)
+			b.ReportMetric(float64(after.TotalAlloc-before.TotalAlloc)/float64(b.N)/float64(size), "alloc-bytes/value-byte")
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package env
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// DefaultStopMarkers lists the files or directories that end the upward search
+// made by LoadUp and OverloadUp when they are given nil markers. A directory
+// containing any of them is treated as the project root: it is searched, but
+// its parents are not.
+var DefaultStopMarkers = []string{"go.mod", ".git"}
+
+// FindFile looks for name in the current working directory and then in each
+// parent directory in turn, returning the path of the nearest match.
+//
+// The search ends at the filesystem root, or after the first directory that
+// contains one of the stopAt markers (for example "go.mod" or ".git"). This
+// lets tests and binaries run from a subdirectory of a repository still find
+// the .env file at its root:
+//
+//	path, err := env.FindFile(".env", "go.mod")
+//
+// If name is absolute it is returned as is when it exists. If no file is
+// found, the returned error is an *fs.PathError wrapping fs.ErrNotExist.
+func FindFile(name string, stopAt ...string) (string, error) {
+	if filepath.IsAbs(name) {
+		if isFile(name) {
+			return name, nil
+		}
+		return "", &fs.PathError{Op: "find", Path: name, Err: fs.ErrNotExist}
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		candidate := filepath.Join(dir, name)
+		if isFile(candidate) {
+			return candidate, nil
+		}
+		if containsAny(dir, stopAt) {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return "", &fs.PathError{Op: "find", Path: name, Err: fs.ErrNotExist}
+}
+
+// LoadUp is like Load, but locates each file with FindFile, walking up from the
+// current working directory until a directory containing one of the stopAt
+// markers. A nil stopAt means DefaultStopMarkers, and an empty one searches up
+// to the filesystem root. If no filenames are provided, it looks for ".env".
+//
+//	err := env.LoadUp(nil)                             // .env, stopping at go.mod or .git
+//	err := env.LoadUp([]string{".git"}, ".env.local") // stopping at .git only
+//
+// Like Load, it will NOT override environment variables that are already set.
+// It returns an error if any file cannot be found, read or parsed.
+func LoadUp(stopAt []string, filenames ...string) error {
+	paths, err := findFiles(filenamesOrDefault(filenames), stopAt)
+	if err != nil {
+		return err
+	}
+	return Load(paths...)
+}
+
+// OverloadUp is like Overload, but locates each file the same way as LoadUp.
+//
+// Like Overload, it WILL override environment variables that are already set.
+func OverloadUp(stopAt []string, filenames ...string) error {
+	paths, err := findFiles(filenamesOrDefault(filenames), stopAt)
+	if err != nil {
+		return err
+	}
+	return Overload(paths...)
+}
+
+// findFiles locates filenames with FindFile, stopping at DefaultStopMarkers
+// when stopAt is nil.
+func findFiles(filenames, stopAt []string) ([]string, error) {
+	if stopAt == nil {
+		stopAt = DefaultStopMarkers
+	}
+	paths := make([]string, 0, len(filenames))
+	for _, filename := range filenames {
+		path, err := FindFile(filename, stopAt...)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+func containsAny(dir string, names []string) bool {
+	for _, name := range names {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package env
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// chdir changes the working directory for the duration of the test.
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+}
+
+// makeTree creates the given files (with the given contents) under a new
+// temporary directory and returns its path with symlinks resolved.
+func makeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestFindFile(t *testing.T) {
+	root := makeTree(t, map[string]string{
+		"repo/go.mod":          "module example",
+		"repo/.env":            "FIND_FILE_A=root",
+		"repo/svc/.env.local":  "FIND_FILE_A=svc",
+		"repo/svc/pkg/a/b.txt": "",
+	})
+	chdir(t, filepath.Join(root, "repo", "svc", "pkg", "a"))
+
+	path, err := FindFile(".env", "go.mod")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "repo", ".env"), path)
+
+	path, err = FindFile(".env.local", "go.mod")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "repo", "svc", ".env.local"), path)
+
+	// directories never match
+	_, err = FindFile("svc", "go.mod")
+	assert.True(t, errors.Is(err, fs.ErrNotExist))
+
+	// the search does not go past the marker
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "outside.env"), nil, 0600))
+	_, err = FindFile("outside.env", "go.mod")
+	assert.True(t, errors.Is(err, fs.ErrNotExist))
+
+	// without markers the search continues to the root
+	path, err = FindFile("outside.env")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "outside.env"), path)
+
+	// absolute paths are not searched for
+	path, err = FindFile(filepath.Join(root, "repo", ".env"))
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "repo", ".env"), path)
+	_, err = FindFile(filepath.Join(root, "nope.env"))
+	assert.True(t, errors.Is(err, fs.ErrNotExist))
+}
+
+func TestLoadUp(t *testing.T) {
+	root := makeTree(t, map[string]string{
+		"repo/go.mod":      "module example",
+		"repo/.env":        "LOAD_UP_A=root\nLOAD_UP_B=root",
+		"repo/svc/app.env": "LOAD_UP_B=app",
+		"repo/svc/cmd/x":   "",
+	})
+	chdir(t, filepath.Join(root, "repo", "svc", "cmd"))
+	UnsetForTest(t, "LOAD_UP_A")
+	UnsetForTest(t, "LOAD_UP_B")
+
+	assert.NoError(t, LoadUp(nil))
+	assert.Equal(t, "root", os.Getenv("LOAD_UP_A"))
+	assert.Equal(t, "root", os.Getenv("LOAD_UP_B"))
+
+	assert.NoError(t, OverloadUp(nil, "app.env"))
+	assert.Equal(t, "app", os.Getenv("LOAD_UP_B"))
+
+	err := LoadUp(nil, "missing.env")
+	assert.True(t, errors.Is(err, fs.ErrNotExist))
+	assert.Error(t, OverloadUp(nil, "missing.env"))
+}
+
+func TestLoadUpStopMarkers(t *testing.T) {
+	root := makeTree(t, map[string]string{
+		"outer.env":      "LOAD_UP_MARKERS=outer",
+		"repo/go.mod":    "module example",
+		"repo/.env":      "LOAD_UP_MARKERS=root",
+		"repo/svc/.stop": "",
+		"repo/svc/cmd/x": "",
+	})
+	chdir(t, filepath.Join(root, "repo", "svc", "cmd"))
+	UnsetForTest(t, "LOAD_UP_MARKERS")
+
+	err := LoadUp([]string{".stop"})
+	assert.True(t, errors.Is(err, fs.ErrNotExist), "the search stops at svc")
+
+	assert.NoError(t, OverloadUp([]string{}, "outer.env"))
+	assert.Equal(t, "outer", os.Getenv("LOAD_UP_MARKERS"), "no markers searches up to the root")
+
+	assert.NoError(t, OverloadUp(nil))
+	assert.Equal(t, "root", os.Getenv("LOAD_UP_MARKERS"), "nil markers are DefaultStopMarkers")
+}
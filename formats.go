@@ -0,0 +1,1003 @@
+package env
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format identifies the syntax of a configuration file.
+type Format int
+
+const (
+	// FormatAuto selects the format from the file extension (see FormatForFile).
+	FormatAuto Format = iota
+	// FormatDotenv is the .env syntax understood by ParseIO.
+	FormatDotenv
+	// FormatJSON is a JSON document whose top level is an object.
+	FormatJSON
+	// FormatYAML is a YAML document whose top level is a mapping.
+	FormatYAML
+	// FormatTOML is a TOML document. Tables, dotted and quoted keys, inline
+	// tables, arrays, arrays of tables and all string forms are supported;
+	// numbers and dates are passed through as written.
+	FormatTOML
+	// FormatINI is an INI file with optional [section] headers.
+	FormatINI
+	// FormatProperties is a Java .properties file.
+	FormatProperties
+	// FormatShell is a POSIX shell script of "export KEY='value'" lines, safe
+	// to eval. It is parsed with DialectPOSIX.
+	FormatShell
+	// FormatFish is a fish shell script of "set -gx KEY 'value'" lines. It can
+	// only be written.
+	FormatFish
+	// FormatSystemd is a systemd EnvironmentFile. It is parsed with DialectSystemd.
+	FormatSystemd
+	// FormatDocker is a file for docker run --env-file: KEY=value lines with
+	// no quoting or escaping at all.
+	FormatDocker
+	// FormatConfigMap is a Kubernetes ConfigMap manifest. It can only be written.
+	FormatConfigMap
+	// FormatSecret is a Kubernetes Secret manifest with base64 encoded
+	// values. It can only be written.
+	FormatSecret
+)
+
+// ErrUnsupportedFormat is returned when a Format value is not supported by
+// the requested operation.
+var ErrUnsupportedFormat = errors.New("unsupported format")
+
+var formatNames = map[Format]string{
+	FormatAuto:       "auto",
+	FormatDotenv:     "dotenv",
+	FormatJSON:       "json",
+	FormatYAML:       "yaml",
+	FormatTOML:       "toml",
+	FormatINI:        "ini",
+	FormatProperties: "properties",
+	FormatShell:      "shell",
+	FormatFish:       "fish",
+	FormatSystemd:    "systemd",
+	FormatDocker:     "docker",
+	FormatConfigMap:  "configmap",
+	FormatSecret:     "secret",
+}
+
+// String returns the lower case name of the format.
+func (f Format) String() string {
+	if name, ok := formatNames[f]; ok {
+		return name
+	}
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// FormatForFile returns the format implied by the extension of filename:
+// .json, .yaml or .yml, .toml, .ini and .properties select the matching format;
+// anything else, including .env, .env.local and extensionless files, is
+// treated as FormatDotenv.
+func FormatForFile(filename string) Format {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		return FormatJSON
+	case ".yaml", ".yml":
+		return FormatYAML
+	case ".toml":
+		return FormatTOML
+	case ".ini":
+		return FormatINI
+	case ".properties":
+		return FormatProperties
+	}
+	return FormatDotenv
+}
+
+// ParseFormat reads configuration in the given format from r and returns it as
+// a map of environment variable names to values.
+//
+// Structured formats are flattened so that they merge naturally with .env
+// files: nested keys are joined with underscores and upper-cased, and any
+// character other than a letter, digit or underscore becomes an underscore,
+// so {"db": {"host": "x"}} and db.host=x both produce DB_HOST=x.
+// Arrays of scalars are joined with commas (the default separator for slice
+// fields in Parse); arrays containing objects are flattened by index
+// (SERVERS_0_HOST). Null values become empty strings.
+//
+// Two different keys that flatten to the same name, such as "db.host" and
+// "DB_HOST", are reported as an error.
+//
+// FormatShell, FormatSystemd and FormatDocker files are read as flat lists
+// of variables, keeping key names as written. The write-only formats
+// FormatFish, FormatConfigMap and FormatSecret report ErrUnsupportedFormat.
+func ParseFormat(r io.Reader, format Format) (map[string]string, error) {
+	switch format {
+	case FormatDotenv:
+		return ParseIO(r)
+	case FormatJSON:
+		return parseJSON(r)
+	case FormatYAML:
+		return parseYAML(r)
+	case FormatTOML:
+		return parseTOML(r)
+	case FormatINI:
+		return parseINI(r)
+	case FormatProperties:
+		return parseProperties(r)
+	case FormatShell:
+		return ParseIOWithOptions(r, ParseOptions{Dialect: DialectPOSIX})
+	case FormatSystemd:
+		return ParseIOWithOptions(r, ParseOptions{Dialect: DialectSystemd})
+	case FormatDocker:
+		return parseDockerEnvFile(r)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+}
+
+// ReadFormat is like Read, but parses every file with the given format instead
+// of detecting it from the file extension.
+func ReadFormat(format Format, filenames ...string) (map[string]string, error) {
+	return readFiles(formatReader(format), filenames)
+}
+
+// LoadFormat is like Load, but parses every file with the given format instead
+// of detecting it from the file extension.
+func LoadFormat(format Format, filenames ...string) error {
+	return loadFiles(formatReader(format), filenames, false)
+}
+
+// OverloadFormat is like Overload, but parses every file with the given format
+// instead of detecting it from the file extension.
+func OverloadFormat(format Format, filenames ...string) error {
+	return loadFiles(formatReader(format), filenames, true)
+}
+
+// formatReader returns a fileReader that parses files from disk with format,
+// or with the format implied by each file's extension for FormatAuto.
+func formatReader(format Format) fileReader {
+	return func(filename string) (map[string]string, error) {
+		return readFileFormat(filename, format)
+	}
+}
+
+func readFileFormat(filename string, format Format) (map[string]string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return parseFileFormat(file, filename, format, osIncludes{})
+}
+
+// parseFileFormat parses r, read from filename, with format. Include
+// directives in .env files are opened through files.
+func parseFileFormat(r io.Reader, filename string, format Format, files includeFS) (map[string]string, error) {
+	if format == FormatAuto {
+		format = FormatForFile(filename)
+	}
+	if format == FormatDotenv {
+		return newDotenvParser(files).withOptions(ParseOptions{EncryptionKeys: DecryptionKeys}).parse(r, filename)
+	}
+	envMap, err := ParseFormat(r, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return envMap, nil
+}
+
+// flattener collects nested configuration values into env-style keys,
+// remembering which source key produced each name to report collisions.
+type flattener struct {
+	envMap  map[string]string
+	origins map[string]string
+}
+
+func newFlattener() *flattener {
+	return &flattener{envMap: make(map[string]string), origins: make(map[string]string)}
+}
+
+// add flattens value found at path. Maps are map[string]interface{}, arrays
+// are []interface{}, and scalars are strings, json.Number, bool or nil.
+func (f *flattener) add(path []string, value interface{}) error {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if err := f.add(append(path[:len(path):len(path)], k), v[k]); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []interface{}:
+		if isScalarList(v) {
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = scalarString(item)
+			}
+			return f.set(path, strings.Join(items, ","))
+		}
+		for i, item := range v {
+			if err := f.add(append(path[:len(path):len(path)], strconv.Itoa(i)), item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return f.set(path, scalarString(value))
+}
+
+func (f *flattener) set(path []string, value string) error {
+	key := flattenKey(path)
+	origin := strings.Join(path, ".")
+	if previous, ok := f.origins[key]; ok && previous != origin {
+		return fmt.Errorf("keys %q and %q both map to %s", previous, origin, key)
+	}
+	f.origins[key] = origin
+	f.envMap[key] = value
+	return nil
+}
+
+// flattenKey joins a key path into an environment variable name.
+func flattenKey(path []string) string {
+	return strings.Map(func(c rune) rune {
+		switch {
+		case c >= 'a' && c <= 'z':
+			return c - 'a' + 'A'
+		case c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '_':
+			return c
+		}
+		return '_'
+	}, strings.Join(path, "_"))
+}
+
+func isScalarList(items []interface{}) bool {
+	for _, item := range items {
+		switch item.(type) {
+		case map[string]interface{}, []interface{}:
+			return false
+		}
+	}
+	return true
+}
+
+func scalarString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return fmt.Sprint(value)
+}
+
+func parseJSON(r io.Reader) (map[string]string, error) {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+	root, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("top-level JSON value must be an object")
+	}
+
+	f := newFlattener()
+	if err := f.add(nil, root); err != nil {
+		return nil, err
+	}
+	return f.envMap, nil
+}
+
+func parseYAML(r io.Reader) (map[string]string, error) {
+	var doc yaml.Node
+	if err := yaml.NewDecoder(r).Decode(&doc); err != nil {
+		if errors.Is(err, io.EOF) {
+			return make(map[string]string), nil
+		}
+		return nil, err
+	}
+
+	root, err := yamlValue(&doc)
+	if err != nil {
+		return nil, err
+	}
+	if root == nil {
+		return make(map[string]string), nil
+	}
+	if _, ok := root.(map[string]interface{}); !ok {
+		return nil, errors.New("top-level YAML value must be a mapping")
+	}
+
+	f := newFlattener()
+	if err := f.add(nil, root); err != nil {
+		return nil, err
+	}
+	return f.envMap, nil
+}
+
+// yamlValue converts a YAML node into the values understood by flattener.
+// Scalars keep their literal text, so 0755 or 1.50 are not reformatted.
+func yamlValue(node *yaml.Node) (interface{}, error) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		return yamlValue(node.Content[0])
+	case yaml.AliasNode:
+		return yamlValue(node.Alias)
+	case yaml.SequenceNode:
+		items := make([]interface{}, 0, len(node.Content))
+		for _, child := range node.Content {
+			item, err := yamlValue(child)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	case yaml.MappingNode:
+		m := make(map[string]interface{})
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			value, err := yamlValue(node.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			if key := node.Content[i]; key.Value == "<<" && key.Tag == "!!merge" {
+				// merged keys never override keys set explicitly
+				if merged, ok := value.(map[string]interface{}); ok {
+					for k, v := range merged {
+						if _, exists := m[k]; !exists {
+							m[k] = v
+						}
+					}
+				}
+				continue
+			}
+			m[node.Content[i].Value] = value
+		}
+		return m, nil
+	case yaml.ScalarNode:
+		if node.Tag == "!!null" {
+			return nil, nil
+		}
+		return node.Value, nil
+	}
+	return nil, fmt.Errorf("line %d: unsupported YAML node", node.Line)
+}
+
+// parseINI reads INI files: "key = value" or "key: value" pairs, optionally
+// grouped under [section] headers, with full line comments starting with
+// ";" or "#". Keys in a section are prefixed with the section name, and
+// values wrapped in matching quotes are unquoted.
+func parseINI(r io.Reader) (map[string]string, error) {
+	f := newFlattener()
+	var section []string
+
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == ';' || line[0] == '#' {
+			continue
+		}
+		if line[0] == '[' {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: unterminated section header", lineNumber)
+			}
+			section = strings.Split(strings.TrimSpace(line[1:len(line)-1]), ".")
+			continue
+		}
+
+		sep := strings.IndexAny(line, "=:")
+		if sep == -1 {
+			return nil, fmt.Errorf("line %d: can't separate key from value", lineNumber)
+		}
+		key := strings.TrimSpace(line[:sep])
+		value := unquote(strings.TrimSpace(line[sep+1:]))
+		if err := f.set(append(section[:len(section):len(section)], key), value); err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return f.envMap, nil
+}
+
+func unquote(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// parseProperties reads Java .properties files as described by
+// java.util.Properties.load: "key=value", "key: value" or "key value" pairs,
+// comments starting with "#" or "!", backslash line continuations and
+// backslash escapes including \uXXXX.
+func parseProperties(r io.Reader) (map[string]string, error) {
+	f := newFlattener()
+
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		start := lineNumber
+		line := strings.TrimLeft(scanner.Text(), " \t\f")
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+		for continuesOnNextLine(line) && scanner.Scan() {
+			lineNumber++
+			line = line[:len(line)-1] + strings.TrimLeft(scanner.Text(), " \t\f")
+		}
+
+		key, value, err := splitProperty(line)
+		if err == nil {
+			err = f.set([]string{key}, value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", start, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return f.envMap, nil
+}
+
+// continuesOnNextLine reports whether line ends with an odd number of backslashes.
+func continuesOnNextLine(line string) bool {
+	n := len(line) - len(strings.TrimRight(line, "\\"))
+	return n%2 == 1
+}
+
+func splitProperty(line string) (key, value string, err error) {
+	end := len(line)
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\\' {
+			i++
+			continue
+		}
+		if strings.IndexByte("=: \t\f", line[i]) != -1 {
+			end = i
+			break
+		}
+	}
+
+	rest := strings.TrimLeft(line[end:], " \t\f")
+	if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeft(rest[1:], " \t\f")
+	}
+
+	if key, err = unescapeProperty(line[:end]); err != nil {
+		return
+	}
+	value, err = unescapeProperty(rest)
+	return
+}
+
+func unescapeProperty(s string) (string, error) {
+	if !strings.Contains(s, "\\") {
+		return s, nil
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			sb.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 't':
+			sb.WriteByte('\t')
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'u':
+			if i+4 >= len(s) {
+				return "", errors.New(`malformed \uXXXX escape`)
+			}
+			code, err := strconv.ParseUint(s[i+1:i+5], 16, 16)
+			if err != nil {
+				return "", errors.New(`malformed \uXXXX escape`)
+			}
+			sb.WriteRune(rune(code))
+			i += 4
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+	return sb.String(), nil
+}
+
+// tomlParser is a small recursive descent parser for TOML documents that
+// produces the values understood by flattener.
+type tomlParser struct {
+	src  string
+	pos  int
+	root map[string]interface{}
+}
+
+func parseTOML(r io.Reader) (map[string]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &tomlParser{src: string(data), root: make(map[string]interface{})}
+	if err := p.parse(); err != nil {
+		return nil, fmt.Errorf("line %d: %w", p.line(), err)
+	}
+
+	f := newFlattener()
+	if err := f.add(nil, p.root); err != nil {
+		return nil, err
+	}
+	return f.envMap, nil
+}
+
+func (p *tomlParser) parse() error {
+	table := p.root
+	for {
+		p.skipSpaceAndComments()
+		if p.eof() {
+			return nil
+		}
+
+		var err error
+		if p.peek() == '[' {
+			table, err = p.parseHeader()
+		} else {
+			err = p.parseKeyValue(table)
+		}
+		if err != nil {
+			return err
+		}
+		if err = p.endOfLine(); err != nil {
+			return err
+		}
+	}
+}
+
+// parseHeader reads a [table] or [[array.of.tables]] header and returns the
+// table that following key/value pairs belong to.
+func (p *tomlParser) parseHeader() (map[string]interface{}, error) {
+	arrayOfTables := strings.HasPrefix(p.src[p.pos:], "[[")
+	if arrayOfTables {
+		p.pos += 2
+	} else {
+		p.pos++
+	}
+
+	path, err := p.parseKey()
+	if err != nil {
+		return nil, err
+	}
+	closing := "]"
+	if arrayOfTables {
+		closing = "]]"
+	}
+	p.skipSpace()
+	if !strings.HasPrefix(p.src[p.pos:], closing) {
+		return nil, fmt.Errorf("expected %q to close table header", closing)
+	}
+	p.pos += len(closing)
+
+	parent, err := tomlTable(p.root, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+	if !arrayOfTables {
+		return tomlTable(parent, []string{last})
+	}
+
+	table := make(map[string]interface{})
+	switch existing := parent[last].(type) {
+	case nil:
+		parent[last] = []interface{}{table}
+	case []interface{}:
+		parent[last] = append(existing, table)
+	default:
+		return nil, fmt.Errorf("key %q is already defined", last)
+	}
+	return table, nil
+}
+
+func (p *tomlParser) parseKeyValue(table map[string]interface{}) error {
+	path, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	p.skipSpace()
+	if p.eof() || p.peek() != '=' {
+		return errors.New("expected '=' after key")
+	}
+	p.pos++
+	p.skipSpace()
+
+	value, err := p.parseValue()
+	if err != nil {
+		return err
+	}
+
+	parent, err := tomlTable(table, path[:len(path)-1])
+	if err != nil {
+		return err
+	}
+	last := path[len(path)-1]
+	if _, exists := parent[last]; exists {
+		return fmt.Errorf("key %q is already defined", last)
+	}
+	parent[last] = value
+	return nil
+}
+
+// tomlTable walks path from table, creating intermediate tables as needed.
+// When a path element is an array of tables, its most recent table is used.
+func tomlTable(table map[string]interface{}, path []string) (map[string]interface{}, error) {
+	for _, key := range path {
+		switch next := table[key].(type) {
+		case nil:
+			child := make(map[string]interface{})
+			table[key] = child
+			table = child
+		case map[string]interface{}:
+			table = next
+		case []interface{}:
+			if len(next) == 0 {
+				return nil, fmt.Errorf("key %q is not a table", key)
+			}
+			last, ok := next[len(next)-1].(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("key %q is not a table", key)
+			}
+			table = last
+		default:
+			return nil, fmt.Errorf("key %q is not a table", key)
+		}
+	}
+	return table, nil
+}
+
+// parseKey reads a bare, quoted or dotted key.
+func (p *tomlParser) parseKey() ([]string, error) {
+	var path []string
+	for {
+		p.skipSpace()
+		if p.eof() {
+			return nil, errors.New("expected a key")
+		}
+
+		var part string
+		var err error
+		switch p.peek() {
+		case '"':
+			part, err = p.parseBasicString()
+		case '\'':
+			part, err = p.parseLiteralString()
+		default:
+			start := p.pos
+			for !p.eof() && isTOMLBareKeyChar(p.peek()) {
+				p.pos++
+			}
+			if start == p.pos {
+				return nil, fmt.Errorf("unexpected character %q in key", p.peek())
+			}
+			part = p.src[start:p.pos]
+		}
+		if err != nil {
+			return nil, err
+		}
+		path = append(path, part)
+
+		p.skipSpace()
+		if p.eof() || p.peek() != '.' {
+			return path, nil
+		}
+		p.pos++
+	}
+}
+
+func isTOMLBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+func (p *tomlParser) parseValue() (interface{}, error) {
+	if p.eof() {
+		return nil, errors.New("expected a value")
+	}
+
+	switch p.peek() {
+	case '"':
+		if strings.HasPrefix(p.src[p.pos:], `"""`) {
+			return p.parseMultilineString(`"""`)
+		}
+		return p.parseBasicString()
+	case '\'':
+		if strings.HasPrefix(p.src[p.pos:], `'''`) {
+			return p.parseMultilineString(`'''`)
+		}
+		return p.parseLiteralString()
+	case '[':
+		return p.parseArray()
+	case '{':
+		return p.parseInlineTable()
+	}
+
+	start := p.pos
+	for !p.eof() && !strings.ContainsRune(" \t\r\n,]}#", rune(p.peek())) {
+		p.pos++
+	}
+	// a date and time may be separated by a space: 1979-05-27 07:32:00Z
+	if p.pos-start == 10 && strings.Count(p.src[start:p.pos], "-") == 2 &&
+		p.pos+1 < len(p.src) && p.src[p.pos] == ' ' && p.src[p.pos+1] >= '0' && p.src[p.pos+1] <= '9' {
+		p.pos++
+		for !p.eof() && !strings.ContainsRune(" \t\r\n,]}#", rune(p.peek())) {
+			p.pos++
+		}
+	}
+
+	token := p.src[start:p.pos]
+	switch {
+	case token == "":
+		return nil, fmt.Errorf("unexpected character %q", p.peek())
+	case token == "true" || token == "false":
+		return token, nil
+	case strings.ContainsAny(token[:1], "+-0123456789") || token == "inf" || token == "nan":
+		if !strings.Contains(token, ":") && strings.Count(token, "-") < 2 {
+			token = strings.ReplaceAll(token, "_", "")
+		}
+		return token, nil
+	}
+	return nil, fmt.Errorf("invalid value %q", token)
+}
+
+func (p *tomlParser) parseArray() (interface{}, error) {
+	p.pos++ // [
+	items := []interface{}{}
+	for {
+		p.skipSpaceAndComments()
+		if p.eof() {
+			return nil, errors.New("unterminated array")
+		}
+		if p.peek() == ']' {
+			p.pos++
+			return items, nil
+		}
+
+		item, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+
+		p.skipSpaceAndComments()
+		if !p.eof() && p.peek() == ',' {
+			p.pos++
+		} else if p.eof() || p.peek() != ']' {
+			return nil, errors.New("expected ',' or ']' in array")
+		}
+	}
+}
+
+func (p *tomlParser) parseInlineTable() (interface{}, error) {
+	p.pos++ // {
+	table := make(map[string]interface{})
+	p.skipSpace()
+	if !p.eof() && p.peek() == '}' {
+		p.pos++
+		return table, nil
+	}
+	for {
+		if err := p.parseKeyValue(table); err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if p.eof() {
+			return nil, errors.New("unterminated inline table")
+		}
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			return table, nil
+		default:
+			return nil, errors.New("expected ',' or '}' in inline table")
+		}
+	}
+}
+
+func (p *tomlParser) parseBasicString() (string, error) {
+	p.pos++ // "
+	var sb strings.Builder
+	for !p.eof() {
+		c := p.peek()
+		switch c {
+		case '"':
+			p.pos++
+			return sb.String(), nil
+		case '\n':
+			return "", errors.New("unterminated string")
+		case '\\':
+			if err := p.parseEscape(&sb); err != nil {
+				return "", err
+			}
+			continue
+		}
+		sb.WriteByte(c)
+		p.pos++
+	}
+	return "", errors.New("unterminated string")
+}
+
+func (p *tomlParser) parseLiteralString() (string, error) {
+	p.pos++ // '
+	end := strings.IndexAny(p.src[p.pos:], "'\n")
+	if end == -1 || p.src[p.pos+end] != '\'' {
+		return "", errors.New("unterminated string")
+	}
+	s := p.src[p.pos : p.pos+end]
+	p.pos += end + 1
+	return s, nil
+}
+
+// parseMultilineString reads a multi-line basic or literal string. A newline directly after
+// the opening delimiter is trimmed; in basic strings a backslash at the end
+// of a line removes the line break and leading whitespace on the next line.
+func (p *tomlParser) parseMultilineString(delim string) (string, error) {
+	p.pos += len(delim)
+	if strings.HasPrefix(p.src[p.pos:], "\r\n") {
+		p.pos += 2
+	} else if strings.HasPrefix(p.src[p.pos:], "\n") {
+		p.pos++
+	}
+
+	var sb strings.Builder
+	for !p.eof() {
+		if strings.HasPrefix(p.src[p.pos:], delim) {
+			p.pos += len(delim)
+			// up to two quotes directly before the closing delimiter belong to the string
+			for i := 0; i < 2 && !p.eof() && p.peek() == delim[0]; i++ {
+				sb.WriteByte(delim[0])
+				p.pos++
+			}
+			return sb.String(), nil
+		}
+		c := p.peek()
+		if c == '\\' && delim == `"""` {
+			rest := strings.TrimLeft(p.src[p.pos+1:], " \t")
+			if strings.HasPrefix(rest, "\n") || strings.HasPrefix(rest, "\r\n") {
+				p.pos = len(p.src) - len(strings.TrimLeft(rest, " \t\r\n"))
+				continue
+			}
+			if err := p.parseEscape(&sb); err != nil {
+				return "", err
+			}
+			continue
+		}
+		sb.WriteByte(c)
+		p.pos++
+	}
+	return "", errors.New("unterminated multi-line string")
+}
+
+func (p *tomlParser) parseEscape(sb *strings.Builder) error {
+	if p.pos+1 >= len(p.src) {
+		return errors.New("unterminated escape sequence")
+	}
+	c := p.src[p.pos+1]
+	p.pos += 2
+	switch c {
+	case 'b':
+		sb.WriteByte('\b')
+	case 't':
+		sb.WriteByte('\t')
+	case 'n':
+		sb.WriteByte('\n')
+	case 'f':
+		sb.WriteByte('\f')
+	case 'r':
+		sb.WriteByte('\r')
+	case '"', '\\':
+		sb.WriteByte(c)
+	case 'u', 'U':
+		size := 4
+		if c == 'U' {
+			size = 8
+		}
+		if p.pos+size > len(p.src) {
+			return fmt.Errorf(`malformed \%c escape`, c)
+		}
+		code, err := strconv.ParseUint(p.src[p.pos:p.pos+size], 16, 32)
+		if err != nil || !utf8.ValidRune(rune(code)) {
+			return fmt.Errorf(`malformed \%c escape`, c)
+		}
+		sb.WriteRune(rune(code))
+		p.pos += size
+	default:
+		return fmt.Errorf(`invalid escape sequence \%c`, c)
+	}
+	return nil
+}
+
+func (p *tomlParser) endOfLine() error {
+	p.skipSpace()
+	if !p.eof() && p.peek() == '#' {
+		p.skipComment()
+	}
+	if p.eof() {
+		return nil
+	}
+	if strings.HasPrefix(p.src[p.pos:], "\r\n") {
+		p.pos += 2
+		return nil
+	}
+	if p.peek() == '\n' {
+		p.pos++
+		return nil
+	}
+	return fmt.Errorf("unexpected %q after value", p.peek())
+}
+
+func (p *tomlParser) skipSpace() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.pos++
+	}
+}
+
+func (p *tomlParser) skipSpaceAndComments() {
+	for !p.eof() {
+		switch c := p.peek(); {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			p.pos++
+		case c == '#':
+			p.skipComment()
+		default:
+			return
+		}
+	}
+}
+
+func (p *tomlParser) skipComment() {
+	for !p.eof() && p.peek() != '\n' {
+		p.pos++
+	}
+}
+
+func (p *tomlParser) peek() byte { return p.src[p.pos] }
+
+func (p *tomlParser) eof() bool { return p.pos >= len(p.src) }
+
+// line returns the 1-based line number of the current position.
+func (p *tomlParser) line() int {
+	return strings.Count(p.src[:p.pos], "\n") + 1
+}
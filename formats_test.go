@@ -0,0 +1,280 @@
+package env
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func parseFormatString(t *testing.T, content string, format Format) map[string]string {
+	t.Helper()
+	envMap, err := ParseFormat(strings.NewReader(content), format)
+	if err != nil {
+		t.Fatalf("unexpected error parsing %s: %v", format, err)
+	}
+	return envMap
+}
+
+func TestFormatForFile(t *testing.T) {
+	assert.Equal(t, FormatJSON, FormatForFile("config.json"))
+	assert.Equal(t, FormatYAML, FormatForFile("config/app.yaml"))
+	assert.Equal(t, FormatYAML, FormatForFile("APP.YML"))
+	assert.Equal(t, FormatTOML, FormatForFile("app.toml"))
+	assert.Equal(t, FormatINI, FormatForFile("app.ini"))
+	assert.Equal(t, FormatProperties, FormatForFile("app.properties"))
+	assert.Equal(t, FormatDotenv, FormatForFile(".env"))
+	assert.Equal(t, FormatDotenv, FormatForFile(".env.local"))
+	assert.Equal(t, FormatDotenv, FormatForFile("settings"))
+
+	assert.Equal(t, "yaml", FormatYAML.String())
+	assert.Equal(t, "Format(99)", Format(99).String())
+}
+
+func TestParseJSON(t *testing.T) {
+	envMap := parseFormatString(t, `{
+		"port": 8080,
+		"ratio": 1.50,
+		"debug": true,
+		"empty": null,
+		"db": {"host": "localhost", "read-replicas": ["a", "b"]},
+		"servers": [{"name": "one"}, {"name": "two"}]
+	}`, FormatJSON)
+
+	assert.Equal(t, map[string]string{
+		"PORT":             "8080",
+		"RATIO":            "1.50",
+		"DEBUG":            "true",
+		"EMPTY":            "",
+		"DB_HOST":          "localhost",
+		"DB_READ_REPLICAS": "a,b",
+		"SERVERS_0_NAME":   "one",
+		"SERVERS_1_NAME":   "two",
+	}, envMap)
+
+	_, err := ParseFormat(strings.NewReader(`["not", "an", "object"]`), FormatJSON)
+	assert.Error(t, err)
+	_, err = ParseFormat(strings.NewReader(`{"db.host": "a", "DB_HOST": "b"}`), FormatJSON)
+	assert.EqualError(t, err, `keys "DB_HOST" and "db.host" both map to DB_HOST`)
+}
+
+func TestParseYAML(t *testing.T) {
+	envMap := parseFormatString(t, `
+defaults: &defaults
+  timeout: 30s
+  mode: 0755
+db:
+  <<: *defaults
+  host: localhost
+  mode: "0600"
+tags: [web, api]
+nothing: ~
+`, FormatYAML)
+
+	assert.Equal(t, map[string]string{
+		"DEFAULTS_TIMEOUT": "30s",
+		"DEFAULTS_MODE":    "0755",
+		"DB_TIMEOUT":       "30s",
+		"DB_HOST":          "localhost",
+		"DB_MODE":          "0600",
+		"TAGS":             "web,api",
+		"NOTHING":          "",
+	}, envMap)
+
+	assert.Empty(t, parseFormatString(t, "", FormatYAML))
+
+	_, err := ParseFormat(strings.NewReader("- a\n- b\n"), FormatYAML)
+	assert.Error(t, err)
+}
+
+func TestParseTOML(t *testing.T) {
+	envMap := parseFormatString(t, `
+# service settings
+title = "TOML \"example\"" # trailing comment
+path = 'C:\Users\nope'
+size = 1_000
+enabled = false
+born = 1979-05-27 07:32:00Z
+ports = [ 8000,
+  8001, # second
+]
+point = { x = 1, y = 2 }
+"quoted key" = "q"
+site."google.com" = true
+motd = """
+Roses are red \
+    and so on"""
+
+[database]
+server = "192.168.1.1"
+
+[database.replica]
+server = "10.0.0.2"
+
+[[products]]
+name = "Hammer"
+
+[[products]]
+name = "Nail"
+`, FormatTOML)
+
+	assert.Equal(t, map[string]string{
+		"TITLE":                   `TOML "example"`,
+		"PATH":                    `C:\Users\nope`,
+		"SIZE":                    "1000",
+		"ENABLED":                 "false",
+		"BORN":                    "1979-05-27 07:32:00Z",
+		"PORTS":                   "8000,8001",
+		"POINT_X":                 "1",
+		"POINT_Y":                 "2",
+		"QUOTED_KEY":              "q",
+		"SITE_GOOGLE_COM":         "true",
+		"MOTD":                    "Roses are red and so on",
+		"DATABASE_SERVER":         "192.168.1.1",
+		"DATABASE_REPLICA_SERVER": "10.0.0.2",
+		"PRODUCTS_0_NAME":         "Hammer",
+		"PRODUCTS_1_NAME":         "Nail",
+	}, envMap)
+
+	for _, invalid := range []string{
+		"a = ",
+		"a = 1\na = 2",
+		"a = \"unterminated",
+		"a = [1, 2",
+		"a = 1 b = 2",
+		"[table",
+		"a = nope",
+	} {
+		_, err := ParseFormat(strings.NewReader(invalid), FormatTOML)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestParseTOMLEmptyArrayIsNotATable(t *testing.T) {
+	for _, input := range []string{
+		"a = []\n[a.b]\nc=1\n",
+		"a = []\na.b = 1\n",
+	} {
+		_, err := ParseFormat(strings.NewReader(input), FormatTOML)
+		assert.EqualError(t, err, `line 2: key "a" is not a table`, input)
+	}
+}
+
+func TestParseINI(t *testing.T) {
+	envMap := parseFormatString(t, `
+; global settings
+name = app
+
+[database]
+host = "localhost"
+port: 5432
+# comment
+
+[cache.redis]
+url = redis://localhost:6379
+`, FormatINI)
+
+	assert.Equal(t, map[string]string{
+		"NAME":            "app",
+		"DATABASE_HOST":   "localhost",
+		"DATABASE_PORT":   "5432",
+		"CACHE_REDIS_URL": "redis://localhost:6379",
+	}, envMap)
+
+	_, err := ParseFormat(strings.NewReader("[section\nkey=value"), FormatINI)
+	assert.Error(t, err)
+	_, err = ParseFormat(strings.NewReader("novalue"), FormatINI)
+	assert.EqualError(t, err, "line 1: can't separate key from value")
+}
+
+func TestParseProperties(t *testing.T) {
+	envMap := parseFormatString(t, `
+# comment
+! another comment
+db.host=localhost
+db.port : 5432
+app.name My App
+fruits = apple, banana, \
+         pear
+key\ with\ spaces = value
+unicode = caf\u00e9
+tabbed = a\tb
+`, FormatProperties)
+
+	assert.Equal(t, map[string]string{
+		"DB_HOST":         "localhost",
+		"DB_PORT":         "5432",
+		"APP_NAME":        "My App",
+		"FRUITS":          "apple, banana, pear",
+		"KEY_WITH_SPACES": "value",
+		"UNICODE":         "café",
+		"TABBED":          "a\tb",
+	}, envMap)
+
+	_, err := ParseFormat(strings.NewReader(`bad = \u00`), FormatProperties)
+	assert.Error(t, err)
+}
+
+func TestParseFormatUnsupported(t *testing.T) {
+	_, err := ParseFormat(strings.NewReader(""), FormatAuto)
+	assert.True(t, errors.Is(err, ErrUnsupportedFormat))
+}
+
+func TestReadDetectsFormat(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"base.env":        "FORMAT_BASE=env\nFORMAT_DB_HOST=from-env",
+		"app.json":        `{"format": {"db": {"host": "from-json"}}}`,
+		"app.yaml":        "format:\n  yaml: true",
+		"app.properties":  "format.props=yes",
+		"json-as-env.txt": `{"a": 1}`,
+	}
+	for name, content := range files {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+	}
+	path := func(name string) string { return filepath.Join(dir, name) }
+
+	envMap, err := Read(path("base.env"), path("app.json"), path("app.yaml"), path("app.properties"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"FORMAT_BASE":    "env",
+		"FORMAT_DB_HOST": "from-json",
+		"FORMAT_YAML":    "true",
+		"FORMAT_PROPS":   "yes",
+	}, envMap)
+
+	// an explicit format wins over the extension
+	envMap, err = ReadFormat(FormatJSON, path("json-as-env.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"A": "1"}, envMap)
+
+	UnsetForTest(t, "FORMAT_DB_HOST")
+	assert.NoError(t, LoadFormat(FormatJSON, path("app.json")))
+	assert.Equal(t, "from-json", os.Getenv("FORMAT_DB_HOST"))
+	assert.NoError(t, OverloadFormat(FormatDotenv, path("base.env")))
+	assert.Equal(t, "from-env", os.Getenv("FORMAT_DB_HOST"))
+
+	// errors name the file
+	_, err = ReadFormat(FormatYAML, path("base.env"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "base.env")
+}
+
+func TestParseFormatFeedsParse(t *testing.T) {
+	envMap := parseFormatString(t, `{"server": {"port": 9090, "hosts": ["a", "b"]}}`, FormatJSON)
+	for key, value := range envMap {
+		SetForTest(t, key, value)
+	}
+
+	type config struct {
+		Port  int      `env:"SERVER_PORT"`
+		Hosts []string `env:"SERVER_HOSTS"`
+	}
+	var cfg config
+	assert.NoError(t, Parse(&cfg))
+	assert.Equal(t, 9090, cfg.Port)
+	assert.Equal(t, []string{"a", "b"}, cfg.Hosts)
+}
@@ -0,0 +1,53 @@
+package env
+
+import (
+	"io/fs"
+)
+
+// LoadFS reads environment variables from .env files in fsys and sets them in
+// the current process. It behaves exactly like Load, but opens files with
+// fsys.Open, so configuration can be embedded in the binary:
+//
+//	//go:embed defaults.env
+//	var defaults embed.FS
+//
+//	err := env.LoadFS(defaults, "defaults.env")
+//
+// Names are slash-separated paths as required by fs.FS. If no names are
+// provided, it defaults to loading ".env" from the root of fsys.
+//
+// Like Load, it will NOT override environment variables that are already set.
+func LoadFS(fsys fs.FS, names ...string) error {
+	return loadFiles(fsReader(fsys), names, false)
+}
+
+// OverloadFS reads environment variables from .env files in fsys and sets them
+// in the current process, overriding any existing environment variables.
+// It behaves exactly like Overload, but opens files with fsys.Open.
+//
+// If no names are provided, it defaults to loading ".env" from the root of fsys.
+func OverloadFS(fsys fs.FS, names ...string) error {
+	return loadFiles(fsReader(fsys), names, true)
+}
+
+// ReadFS parses .env files in fsys and returns the key-value pairs as a map,
+// without setting them as environment variables in the current process.
+// It behaves exactly like Read, but opens files with fsys.Open.
+//
+// If no names are provided, it defaults to reading ".env" from the root of fsys.
+func ReadFS(fsys fs.FS, names ...string) (map[string]string, error) {
+	return readFiles(fsReader(fsys), names)
+}
+
+// fsReader returns a fileReader that opens files in fsys.
+func fsReader(fsys fs.FS) fileReader {
+	return func(name string) (map[string]string, error) {
+		file, err := fsys.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		return parseFileFormat(file, name, FormatAuto, fsIncludes{fsys})
+	}
+}
@@ -0,0 +1,61 @@
+package env
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testFS = fstest.MapFS{
+	".env":             {Data: []byte("FS_A=default\nFS_B=default")},
+	"config/local.env": {Data: []byte("FS_B=local\nFS_C=${FS_B}")},
+	"config/bad.env":   {Data: []byte("INVALID LINE")},
+}
+
+func TestReadFS(t *testing.T) {
+	envMap, err := ReadFS(testFS)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"FS_A": "default", "FS_B": "default"}, envMap)
+
+	envMap, err = ReadFS(testFS, ".env", "config/local.env")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"FS_A": "default", "FS_B": "local", "FS_C": "local"}, envMap)
+
+	_, err = ReadFS(testFS, "config/bad.env")
+	assert.Error(t, err)
+
+	_, err = ReadFS(testFS, "missing.env")
+	assert.True(t, errors.Is(err, fs.ErrNotExist))
+}
+
+func TestLoadFS(t *testing.T) {
+	UnsetForTest(t, "FS_A")
+	UnsetForTest(t, "FS_C")
+	SetForTest(t, "FS_B", "process")
+
+	assert.NoError(t, LoadFS(testFS, ".env", "config/local.env"))
+	assert.Equal(t, "default", os.Getenv("FS_A"))
+	assert.Equal(t, "process", os.Getenv("FS_B"))
+	assert.Equal(t, "local", os.Getenv("FS_C"))
+
+	assert.NoError(t, OverloadFS(testFS, "config/local.env"))
+	assert.Equal(t, "local", os.Getenv("FS_B"))
+
+	assert.Error(t, LoadFS(testFS, "missing.env"))
+	assert.Error(t, OverloadFS(testFS, "config/bad.env"))
+}
+
+func TestLoadFSMatchesDisk(t *testing.T) {
+	fixtures := []string{"equals.env", "exported.env", "plain.env", "quoted.env", "substitutions.env"}
+	for _, fixture := range fixtures {
+		fromDisk, err := Read("fixtures/" + fixture)
+		assert.NoError(t, err)
+		fromFS, err := ReadFS(os.DirFS("fixtures"), fixture)
+		assert.NoError(t, err)
+		assert.Equal(t, fromDisk, fromFS, fixture)
+	}
+}
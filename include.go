@@ -0,0 +1,140 @@
+package env
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// MaxIncludeDepth limits how deeply include directives may nest. A file that
+// includes a file that includes another file has a depth of two.
+var MaxIncludeDepth = 8
+
+var (
+	// ErrIncludeCycle is reported when a .env file directly or indirectly
+	// includes itself.
+	ErrIncludeCycle = errors.New("include cycle")
+
+	// ErrIncludeDepth is reported when include directives nest deeper than
+	// MaxIncludeDepth.
+	ErrIncludeDepth = errors.New("include depth exceeded")
+)
+
+// IncludeError reports a failure while following include directives. Chain
+// lists the files involved, from the outermost file to the one that failed.
+type IncludeError struct {
+	Chain []string
+	Err   error
+}
+
+func (e *IncludeError) Error() string {
+	return strings.Join(e.Chain, " -> ") + ": " + e.Err.Error()
+}
+
+func (e *IncludeError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	includeCommentRegex = regexp.MustCompile(`^\s*#\s*@include\s+(.+?)\s*$`)
+	sourceRegex         = regexp.MustCompile(`^\s*source\s+([^\s=:].*?)\s*$`)
+)
+
+// includeTarget reports whether line is an include directive, either
+// "# @include path" or shell-style "source path", and returns the path.
+func includeTarget(line string) (string, bool) {
+	m := includeCommentRegex.FindStringSubmatch(line)
+	if m == nil {
+		m = sourceRegex.FindStringSubmatch(stripComment(line))
+	}
+	if m == nil {
+		return "", false
+	}
+	return unquote(m[1]), true
+}
+
+// include parses the file named by an include directive into envMap, as if
+// its lines appeared in place of the directive.
+func (p *dotenvParser) include(target string, envMap map[string]string) error {
+	from := ""
+	if len(p.chain) > 0 {
+		from = p.chain[len(p.chain)-1]
+	}
+	name := p.files.Resolve(from, target)
+	chain := append(p.chain[:len(p.chain):len(p.chain)], name)
+
+	for _, parent := range p.chain {
+		if parent == name {
+			return &IncludeError{Chain: chain, Err: ErrIncludeCycle}
+		}
+	}
+	if p.depth >= MaxIncludeDepth {
+		return &IncludeError{Chain: chain, Err: ErrIncludeDepth}
+	}
+
+	file, err := p.files.Open(name)
+	if err != nil {
+		return &IncludeError{Chain: chain, Err: err}
+	}
+	defer file.Close()
+
+	parent := p.chain
+	p.chain = chain
+	p.depth++
+	defer func() {
+		p.chain = parent
+		p.depth--
+	}()
+
+	err = p.parseInto(file, envMap)
+	var includeErr *IncludeError
+	if err != nil && !errors.As(err, &includeErr) {
+		err = &IncludeError{Chain: chain, Err: err}
+	}
+	return err
+}
+
+// includeFS opens the files named by include directives.
+type includeFS interface {
+	Open(name string) (io.ReadCloser, error)
+
+	// Resolve returns the name of target as included from the file named
+	// from, or relative to the starting point when from is empty.
+	Resolve(from, target string) string
+}
+
+// osIncludes resolves includes on the operating system's filesystem,
+// relative to the directory of the including file.
+type osIncludes struct{}
+
+func (osIncludes) Open(name string) (io.ReadCloser, error) {
+	return os.Open(name)
+}
+
+func (osIncludes) Resolve(from, target string) string {
+	if from == "" || filepath.IsAbs(target) {
+		return filepath.Clean(target)
+	}
+	return filepath.Join(filepath.Dir(from), target)
+}
+
+// fsIncludes resolves includes inside an fs.FS using slash-separated paths.
+type fsIncludes struct {
+	fsys fs.FS
+}
+
+func (f fsIncludes) Open(name string) (io.ReadCloser, error) {
+	return f.fsys.Open(name)
+}
+
+func (fsIncludes) Resolve(from, target string) string {
+	if from == "" {
+		return path.Clean(target)
+	}
+	return path.Join(path.Dir(from), target)
+}
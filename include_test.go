@@ -0,0 +1,163 @@
+package env
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIncludeTarget(t *testing.T) {
+	tests := []struct {
+		line   string
+		target string
+		ok     bool
+	}{
+		{"# @include ./common.env", "./common.env", true},
+		{"#@include common.env  ", "common.env", true},
+		{"source ../shared/base.env", "../shared/base.env", true},
+		{`source "with space.env" # shared`, "with space.env", true},
+		{"  source 'quoted.env'", "quoted.env", true},
+		{"# include common.env", "", false},
+		{"source=value", "", false},
+		{"source = value", "", false},
+		{"source: value", "", false},
+		{"SOURCE_DIR=/tmp", "", false},
+	}
+	for _, tt := range tests {
+		target, ok := includeTarget(tt.line)
+		assert.Equal(t, tt.ok, ok, tt.line)
+		assert.Equal(t, tt.target, target, tt.line)
+	}
+}
+
+func TestReadFollowsIncludes(t *testing.T) {
+	root := makeTree(t, map[string]string{
+		"shared/base.env":   "BASE=base\nHOST=base-host\nsource ./nested.env\n",
+		"shared/nested.env": "NESTED=${BASE}-nested\n",
+		"app/.env":          "HOST=before\n# @include ../shared/base.env\nURL=http://${HOST}\n",
+	})
+
+	envMap, err := Read(filepath.Join(root, "app", ".env"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"BASE":   "base",
+		"HOST":   "base-host",
+		"NESTED": "base-nested",
+		"URL":    "http://base-host",
+	}, envMap)
+}
+
+func TestIncludeCycle(t *testing.T) {
+	root := makeTree(t, map[string]string{
+		"a.env": "A=1\nsource b.env\n",
+		"b.env": "B=1\nsource a.env\n",
+	})
+
+	_, err := Read(filepath.Join(root, "a.env"))
+	assert.True(t, errors.Is(err, ErrIncludeCycle))
+	var includeErr *IncludeError
+	assert.True(t, errors.As(err, &includeErr))
+	assert.Len(t, includeErr.Chain, 3)
+	assert.True(t, strings.HasSuffix(err.Error(), "a.env -> "+filepath.Join(root, "b.env")+" -> "+filepath.Join(root, "a.env")+": include cycle"), err.Error())
+}
+
+func TestIncludeDepth(t *testing.T) {
+	root := makeTree(t, map[string]string{
+		"0.env": "source 1.env",
+		"1.env": "source 2.env",
+		"2.env": "source 3.env",
+		"3.env": "DEEP=yes",
+	})
+
+	old := MaxIncludeDepth
+	defer func() { MaxIncludeDepth = old }()
+
+	MaxIncludeDepth = 3
+	envMap, err := Read(filepath.Join(root, "0.env"))
+	assert.NoError(t, err)
+	assert.Equal(t, "yes", envMap["DEEP"])
+
+	MaxIncludeDepth = 2
+	_, err = Read(filepath.Join(root, "0.env"))
+	assert.True(t, errors.Is(err, ErrIncludeDepth))
+}
+
+func TestIncludeErrorsShowChain(t *testing.T) {
+	root := makeTree(t, map[string]string{
+		"main.env":   "source middle.env",
+		"middle.env": "source broken.env\nsource missing.env",
+		"broken.env": "not a valid line",
+	})
+	main := filepath.Join(root, "main.env")
+
+	_, err := Read(main)
+	assert.EqualError(t, err, strings.Join([]string{
+		main, filepath.Join(root, "middle.env"), filepath.Join(root, "broken.env"),
+	}, " -> ")+": can't separate key from value")
+
+	assert.NoError(t, Write(map[string]string{}, filepath.Join(root, "broken.env")))
+	_, err = Read(main)
+	var includeErr *IncludeError
+	assert.True(t, errors.As(err, &includeErr))
+	assert.Equal(t, filepath.Join(root, "missing.env"), includeErr.Chain[2])
+}
+
+func TestUnmarshalDoesNotFollowIncludes(t *testing.T) {
+	root := makeTree(t, map[string]string{"common.env": "COMMON=1"})
+	chdir(t, root)
+
+	envMap, err := Unmarshal("# @include /etc/hostname")
+	assert.NoError(t, err)
+	assert.Empty(t, envMap)
+
+	envMap, err = Unmarshal("# @include common.env\nOWN=2")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"OWN": "2"}, envMap)
+
+	_, err = Unmarshal("source common.env")
+	assert.EqualError(t, err, "can't separate key from value")
+}
+
+func TestParseIOWithIncludeFS(t *testing.T) {
+	root := makeTree(t, map[string]string{"config/common.env": "COMMON=1"})
+
+	envMap, err := ParseIOWithOptions(strings.NewReader("source config/common.env\nOWN=2"), ParseOptions{IncludeFS: os.DirFS(root)})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"COMMON": "1", "OWN": "2"}, envMap)
+
+	_, err = ParseIOWithOptions(strings.NewReader("# @include /etc/hostname"), ParseOptions{IncludeFS: os.DirFS(root)})
+	assert.Error(t, err, "absolute paths are invalid in an fs.FS")
+}
+
+func TestReadFSFollowsIncludes(t *testing.T) {
+	fsys := fstest.MapFS{
+		"config/app.env":   {Data: []byte("# @include ../shared/db.env\nAPP=1")},
+		"shared/db.env":    {Data: []byte("DB=postgres\nsource ../config/app.env")},
+		"config/other.env": {Data: []byte("source /etc/passwd")},
+	}
+
+	_, err := ReadFS(fsys, "config/app.env")
+	assert.True(t, errors.Is(err, ErrIncludeCycle))
+	assert.EqualError(t, err, "config/app.env -> shared/db.env -> config/app.env: include cycle")
+
+	_, err = ReadFS(fsys, "config/other.env")
+	assert.Error(t, err, "absolute paths are invalid in an fs.FS")
+}
+
+func TestDocumentKeepsIncludes(t *testing.T) {
+	content := "source common.env\nA=1\n# @include other.env\n"
+	doc, err := ParseDocument(strings.NewReader(content))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A"}, doc.Keys())
+
+	doc.Set("A", "2")
+	var sb strings.Builder
+	_, err = doc.WriteTo(&sb)
+	assert.NoError(t, err)
+	assert.Equal(t, "source common.env\nA=2\n# @include other.env\n", sb.String())
+}
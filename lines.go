@@ -0,0 +1,63 @@
+package env
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// lineReader splits its input into lines like bufio.ScanLines, but without
+// bufio.Scanner's fixed maximum token size: a line is only limited by max,
+// when it is positive. Only the current line is held in memory.
+type lineReader struct {
+	r    *bufio.Reader
+	max  int
+	line int // number of the line last returned
+}
+
+func newLineReader(r io.Reader, max int) *lineReader {
+	return &lineReader{r: bufio.NewReader(r), max: max}
+}
+
+// next returns the next line without its "\n" or "\r\n" terminator, or io.EOF
+// once the input is exhausted. A final line without a terminator is returned
+// as a normal line.
+func (l *lineReader) next() (string, error) {
+	var buf []byte
+	for {
+		chunk, err := l.r.ReadSlice('\n')
+		buf = append(buf, chunk...)
+		// allow for the terminator until the whole line has been read
+		if l.max > 0 && len(buf) > l.max+2 {
+			return "", l.tooLong()
+		}
+
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err == io.EOF && len(buf) == 0 {
+			return "", io.EOF
+		}
+		if err != nil && err != io.EOF {
+			return "", err
+		}
+		break
+	}
+
+	l.line++
+	if n := len(buf); n > 0 && buf[n-1] == '\n' {
+		buf = buf[:n-1]
+	}
+	if n := len(buf); n > 0 && buf[n-1] == '\r' {
+		buf = buf[:n-1]
+	}
+	if l.max > 0 && len(buf) > l.max {
+		l.line--
+		return "", l.tooLong()
+	}
+	return string(buf), nil
+}
+
+func (l *lineReader) tooLong() error {
+	return fmt.Errorf("line %d: %w (limit is %d bytes)", l.line+1, ErrLineTooLong, l.max)
+}
@@ -0,0 +1,78 @@
+package env
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readAllLines(t *testing.T, input string, max int) ([]string, error) {
+	t.Helper()
+	lr := newLineReader(strings.NewReader(input), max)
+	var lines []string
+	for {
+		line, err := lr.next()
+		if err == io.EOF {
+			return lines, nil
+		}
+		if err != nil {
+			return lines, err
+		}
+		lines = append(lines, line)
+	}
+}
+
+func TestLineReader(t *testing.T) {
+	tests := map[string][]string{
+		"":                 nil,
+		"\n":               {""},
+		"a\nb":             {"a", "b"},
+		"a\r\nb\r\n":       {"a", "b"},
+		"a\n\nb\n":         {"a", "", "b"},
+		"trailing\rcr\r\n": {"trailing\rcr"},
+	}
+	for input, expected := range tests {
+		lines, err := readAllLines(t, input, 0)
+		assert.NoError(t, err, "%q", input)
+		assert.Equal(t, expected, lines, "%q", input)
+	}
+}
+
+func TestLineReaderLongLines(t *testing.T) {
+	long := strings.Repeat("x", 1<<20)
+
+	lines, err := readAllLines(t, "A=1\n"+long+"\nB=2", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A=1", long, "B=2"}, lines)
+
+	// the limit excludes the terminator
+	lines, err = readAllLines(t, "12345\r\n1234", 5)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"12345", "1234"}, lines)
+
+	lines, err = readAllLines(t, "ok\n123456\n", 5)
+	assert.True(t, errors.Is(err, ErrLineTooLong))
+	assert.EqualError(t, err, "line 2: line too long (limit is 5 bytes)")
+	assert.Equal(t, []string{"ok"}, lines)
+
+	_, err = readAllLines(t, "ok\n"+long, 4096)
+	assert.EqualError(t, err, "line 2: line too long (limit is 4096 bytes)")
+}
+
+func TestParseIOLongValue(t *testing.T) {
+	keystore := strings.Repeat("QUJD", 50000) // 200 KB of base64, beyond bufio.Scanner's limit
+
+	envMap, err := ParseIO(strings.NewReader("KEYSTORE=\"" + keystore + "\"\nNEXT=1\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, keystore, envMap["KEYSTORE"])
+	assert.Equal(t, "1", envMap["NEXT"])
+
+	_, err = ParseIOWithOptions(strings.NewReader("NEXT=1\nKEYSTORE="+keystore), ParseOptions{MaxLineLength: 64 << 10})
+	assert.True(t, errors.Is(err, ErrLineTooLong))
+	assert.Contains(t, err.Error(), "line 2")
+
+	assert.Empty(t, Lint(strings.NewReader("KEYSTORE="+keystore)))
+}
@@ -0,0 +1,175 @@
+package env
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Severity classifies a Diagnostic.
+type Severity int
+
+const (
+	// SeverityInfo marks stylistic issues that don't change how a file is parsed.
+	SeverityInfo Severity = iota
+	// SeverityWarning marks lines that parse, but probably not as intended.
+	SeverityWarning
+	// SeverityError marks lines that ParseIO rejects or misreads.
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// Diagnostic describes a problem found by Lint on a single line.
+type Diagnostic struct {
+	Line     int // 1-based line number
+	Severity Severity
+	Message  string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("line %d: %s: %s", d.Line, d.Severity, d.Message)
+}
+
+// Lint checks a .env file for lines that ParseIO accepts silently but that are
+// likely mistakes, as well as lines it can't parse at all. It reports:
+//   - duplicate keys (the last definition wins when parsing)
+//   - key names that don't match [A-Z_][A-Z0-9_]*
+//   - unterminated quotes
+//   - trailing whitespace
+//   - references to variables not defined earlier in the file, which expand
+//     to an empty string
+//   - files mixing KEY=value and KEY: value separators
+//   - lines that can't be parsed
+//
+// Diagnostics are returned in line order. Include directives are not followed.
+func Lint(r io.Reader) []Diagnostic {
+	var diags []Diagnostic
+	report := func(line int, severity Severity, format string, args ...interface{}) {
+		diags = append(diags, Diagnostic{Line: line, Severity: severity, Message: fmt.Sprintf(format, args...)})
+	}
+
+	envMap := make(map[string]string)
+	defined := make(map[string]int)
+	var firstSep byte
+	firstSepLine := 0
+
+	lines := newLineReader(r, 0)
+	for {
+		line, err := lines.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			report(lines.line+1, SeverityError, "%v", err)
+			break
+		}
+		lineNo := lines.line
+
+		if strings.TrimRight(line, " \t") != line {
+			report(lineNo, SeverityInfo, "trailing whitespace")
+		}
+		if _, ok := includeTarget(line); ok || isIgnoredLine(line) {
+			continue
+		}
+
+		code := stripComment(line)
+		sep := separatorIndex(code)
+		if sep == -1 {
+			report(lineNo, SeverityError, "can't separate key from value")
+			continue
+		}
+
+		if firstSep == 0 {
+			firstSep, firstSepLine = code[sep], lineNo
+		} else if code[sep] != firstSep {
+			report(lineNo, SeverityWarning, "separator %q differs from %q used on line %d", code[sep], firstSep, firstSepLine)
+		}
+
+		key := parseKey(code[:sep])
+		if !isValidEnvVarKey(key) {
+			report(lineNo, SeverityWarning, "invalid key %q: names should match [A-Z_][A-Z0-9_]*", key)
+		}
+		if first, ok := defined[key]; ok {
+			report(lineNo, SeverityWarning, "duplicate key %q, first defined on line %d", key, first)
+		} else {
+			defined[key] = lineNo
+		}
+
+		raw := strings.Trim(code[sep+1:], " ")
+		if quote, ok := unterminatedQuote(raw); ok {
+			report(lineNo, SeverityError, "unterminated %s quote", quote)
+		} else if !strings.HasPrefix(raw, "'") {
+			for _, name := range variableReferences(raw) {
+				if _, ok := envMap[name]; !ok {
+					report(lineNo, SeverityWarning, "undefined variable %q", name)
+				}
+			}
+		}
+
+		envMap[key] = parseValue(code[sep+1:], envMap)
+	}
+
+	return diags
+}
+
+// LintFile runs Lint on the named file.
+func LintFile(filename string) ([]Diagnostic, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return Lint(file), nil
+}
+
+// unterminatedQuote reports whether value opens a quote it never closes,
+// returning the name of the quote.
+func unterminatedQuote(value string) (string, bool) {
+	if value == "" || (value[0] != '"' && value[0] != '\'') {
+		return "", false
+	}
+	quote := value[0]
+	escaped := false
+	for i := 1; i < len(value); i++ {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && value[i] == '\\':
+			escaped = true
+		case value[i] == quote:
+			return "", false
+		}
+	}
+	if quote == '"' {
+		return "double", true
+	}
+	return "single", true
+}
+
+var variableReferenceRegex = regexp.MustCompile(`(\\)?\$(\()?\{?([A-Z0-9_]+)?`)
+
+// variableReferences returns the names of the variables that expandVariables
+// would substitute in value.
+func variableReferences(value string) []string {
+	var names []string
+	for _, m := range variableReferenceRegex.FindAllStringSubmatch(value, -1) {
+		if m[1] == "" && m[2] == "" && m[3] != "" {
+			names = append(names, m[3])
+		}
+	}
+	return names
+}
@@ -0,0 +1,79 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func lintString(content string) []Diagnostic {
+	return Lint(strings.NewReader(content))
+}
+
+func TestLintCleanFile(t *testing.T) {
+	content := `# comment
+export HOST=localhost
+PORT=8080 # inline
+URL="http://${HOST}:$PORT/#anchor"
+LITERAL='$NOT_EXPANDED'
+ESCAPED=\$HOME
+source common.env
+`
+	assert.Empty(t, lintString(content))
+}
+
+func TestLint(t *testing.T) {
+	content := strings.Join([]string{
+		"A=1",
+		"A=2",
+		"lower=x",
+		"WITH SPACE=x",
+		`QUOTED="unterminated`,
+		"SINGLE='also # unterminated",
+		"TRAILING=x  ",
+		"REF=${MISSING}-$A",
+		"COLON: value",
+		"garbage",
+	}, "\n")
+
+	assert.Equal(t, []Diagnostic{
+		{Line: 2, Severity: SeverityWarning, Message: `duplicate key "A", first defined on line 1`},
+		{Line: 3, Severity: SeverityWarning, Message: `invalid key "lower": names should match [A-Z_][A-Z0-9_]*`},
+		{Line: 4, Severity: SeverityWarning, Message: `invalid key "WITH SPACE": names should match [A-Z_][A-Z0-9_]*`},
+		{Line: 5, Severity: SeverityError, Message: "unterminated double quote"},
+		{Line: 6, Severity: SeverityError, Message: "unterminated single quote"},
+		{Line: 7, Severity: SeverityInfo, Message: "trailing whitespace"},
+		{Line: 8, Severity: SeverityWarning, Message: `undefined variable "MISSING"`},
+		{Line: 9, Severity: SeverityWarning, Message: `separator ':' differs from '=' used on line 1`},
+		{Line: 10, Severity: SeverityError, Message: "can't separate key from value"},
+	}, lintString(content))
+}
+
+func TestLintEscapedQuoteIsNotATerminator(t *testing.T) {
+	diags := lintString(`A="ends with \"`)
+	assert.Len(t, diags, 1)
+	assert.Equal(t, "unterminated double quote", diags[0].Message)
+
+	assert.Empty(t, lintString(`A="ends with \\"`))
+}
+
+func TestDiagnosticString(t *testing.T) {
+	d := Diagnostic{Line: 3, Severity: SeverityError, Message: "boom"}
+	assert.Equal(t, "line 3: error: boom", d.String())
+	assert.Equal(t, "Severity(7)", Severity(7).String())
+}
+
+func TestLintFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), ".env")
+	assert.NoError(t, os.WriteFile(filename, []byte("A=1\nA=2\n"), 0600))
+
+	diags, err := LintFile(filename)
+	assert.NoError(t, err)
+	assert.Len(t, diags, 1)
+
+	_, err = LintFile(filepath.Join(t.TempDir(), "missing.env"))
+	assert.Error(t, err)
+}
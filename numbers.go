@@ -0,0 +1,239 @@
+package env
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// NumberFormat selects the integer syntax accepted by ParseIntFormat,
+// ParseUintFormat and the parsers they back. The zero value accepts the same
+// plain decimal numbers as ParseInt.
+//
+// Struct fields opt in with the envBase and envNumberFormat tags:
+//
+//	type Config struct {
+//		Mode     int    `env:"MODE" envNumberFormat:"prefixes"`   // 0o755, 0x1F, 1_000_000
+//		Mask     uint64 `env:"MASK" envBase:"16"`                 // ff00
+//		MaxBytes int64  `env:"MAX_BYTES" envNumberFormat:"si,iec"` // 10k, 5M, 2Gi
+//	}
+type NumberFormat struct {
+	// Base is the base of numbers without a prefix, from 2 to 36. Zero means
+	// 10.
+	Base int
+	// Prefixes accepts the 0b, 0o and 0x prefixes and the _ digit separators
+	// of Go integer literals, as in 0x1F, 0o755 and 1_000_000. A number with a
+	// leading zero but no prefix is still read in Base. When Base is set, only
+	// the prefix of that base is accepted: in base 16, 0x1F is 31 and 0b1 is
+	// an error rather than binary 1.
+	Prefixes bool
+	// SI accepts the decimal multiplier suffixes k (or K), M, G, T, P and E,
+	// as powers of 1000.
+	SI bool
+	// IEC accepts the binary multiplier suffixes Ki, Mi, Gi, Ti, Pi and Ei,
+	// as powers of 1024.
+	IEC bool
+}
+
+// siSuffixes and iecSuffixes are the multipliers accepted by NumberFormat.
+var (
+	siSuffixes = map[string]uint64{
+		"k": 1e3, "K": 1e3, "M": 1e6, "G": 1e9, "T": 1e12, "P": 1e15, "E": 1e18,
+	}
+	iecSuffixes = map[string]uint64{
+		"Ki": 1 << 10, "Mi": 1 << 20, "Gi": 1 << 30, "Ti": 1 << 40, "Pi": 1 << 50, "Ei": 1 << 60,
+	}
+)
+
+// ParseIntFormat parses s as a signed integer of bitSize bits in the given
+// format. Errors are *strconv.NumError values, as from strconv.ParseInt.
+//
+// A suffix is only taken as a multiplier if s doesn't parse without it, so
+// with Prefixes and SI, 0x1E is 30 rather than 1 exa.
+func ParseIntFormat(s string, format NumberFormat, bitSize int) (int64, error) {
+	const fn = "ParseInt"
+	value, multiplier, err := parseNumber(s, format, func(digits string, base int) (int64, error) {
+		return strconv.ParseInt(digits, base, bitSize)
+	})
+	if err != nil {
+		return value, numError(fn, s, err)
+	}
+	if multiplier == 1 {
+		return value, nil
+	}
+
+	max := int64(1)<<(bitSize-1) - 1
+	min := -max - 1
+	m := int64(multiplier)
+	if value > max/m || value < min/m {
+		return 0, &strconv.NumError{Func: fn, Num: s, Err: strconv.ErrRange}
+	}
+	return value * m, nil
+}
+
+// ParseUintFormat parses s as an unsigned integer of bitSize bits in the
+// given format. See ParseIntFormat.
+func ParseUintFormat(s string, format NumberFormat, bitSize int) (uint64, error) {
+	const fn = "ParseUint"
+	value, multiplier, err := parseNumber(s, format, func(digits string, base int) (uint64, error) {
+		return strconv.ParseUint(digits, base, bitSize)
+	})
+	if err != nil {
+		return value, numError(fn, s, err)
+	}
+	if multiplier == 1 {
+		return value, nil
+	}
+
+	max := uint64(1)<<bitSize - 1
+	if bitSize == 64 {
+		max = ^uint64(0)
+	}
+	if value > max/multiplier {
+		return 0, &strconv.NumError{Func: fn, Num: s, Err: strconv.ErrRange}
+	}
+	return value * multiplier, nil
+}
+
+// parseNumber parses s with parse, trying it whole first and then without a
+// multiplier suffix. It returns the parsed digits and the multiplier.
+func parseNumber[T int64 | uint64](s string, format NumberFormat, parse func(digits string, base int) (T, error)) (T, uint64, error) {
+	value, err := parseDigits(s, format, parse)
+	if err == nil || (!format.SI && !format.IEC) {
+		return value, 1, err
+	}
+
+	digits, multiplier := cutSuffix(s, format)
+	if multiplier == 0 {
+		return value, 1, err
+	}
+	value, err = parseDigits(digits, format, parse)
+	return value, multiplier, err
+}
+
+// parseDigits parses a number without a suffix.
+func parseDigits[T int64 | uint64](s string, format NumberFormat, parse func(digits string, base int) (T, error)) (T, error) {
+	base := format.Base
+	if base == 0 {
+		base = DecimalBase
+	}
+	if !format.Prefixes {
+		return parse(s, base)
+	}
+
+	unsigned := strings.TrimLeft(s, "+-")
+	if len(unsigned) > 2 && unsigned[0] == '0' && strings.ContainsRune("bBoOxX", rune(unsigned[1])) {
+		if format.Base != 0 && format.Base != prefixBases[unsigned[1]|0x20] {
+			var zero T
+			return zero, strconv.ErrSyntax
+		}
+		// strconv checks the prefix and separators of Go literals for base 0.
+		return parse(s, 0)
+	}
+	if strings.Contains(s, "_") {
+		if !validSeparators(unsigned) {
+			var zero T
+			return zero, strconv.ErrSyntax
+		}
+		s = strings.ReplaceAll(s, "_", "")
+	}
+	return parse(s, base)
+}
+
+// prefixBases maps the lower-case letter of a Go integer prefix to its base.
+var prefixBases = map[byte]int{'b': 2, 'o': 8, 'x': 16}
+
+// validSeparators reports whether every _ in digits sits between two digits.
+func validSeparators(digits string) bool {
+	return !strings.HasPrefix(digits, "_") && !strings.HasSuffix(digits, "_") && !strings.Contains(digits, "__")
+}
+
+// cutSuffix removes a multiplier suffix allowed by format from s. The
+// multiplier is 0 if s has none.
+func cutSuffix(s string, format NumberFormat) (string, uint64) {
+	if format.IEC && len(s) > 2 {
+		if m, ok := iecSuffixes[s[len(s)-2:]]; ok {
+			return s[:len(s)-2], m
+		}
+	}
+	if format.SI && len(s) > 1 {
+		if m, ok := siSuffixes[s[len(s)-1:]]; ok {
+			return s[:len(s)-1], m
+		}
+	}
+	return s, 0
+}
+
+// numError reports err against the whole of s, suffix included.
+func numError(fn, s string, err error) error {
+	if numErr, ok := err.(*strconv.NumError); ok {
+		err = numErr.Err
+	}
+	return &strconv.NumError{Func: fn, Num: s, Err: err}
+}
+
+// IntParser returns a Parser for ints in the given format, for use with
+// GetParsed and the other generic functions:
+//
+//	mode, err := env.GetParsed("MODE", env.IntParser(env.NumberFormat{Prefixes: true}))
+func IntParser(format NumberFormat) Parser[int] {
+	return func(s string) (int, error) {
+		v, err := ParseIntFormat(s, format, Int32Bits)
+		return int(v), err
+	}
+}
+
+// Int64Parser returns a Parser for int64s in the given format.
+func Int64Parser(format NumberFormat) Parser[int64] {
+	return func(s string) (int64, error) {
+		return ParseIntFormat(s, format, Int64Bits)
+	}
+}
+
+// UintParser returns a Parser for uints in the given format.
+func UintParser(format NumberFormat) Parser[uint] {
+	return func(s string) (uint, error) {
+		v, err := ParseUintFormat(s, format, Int32Bits)
+		return uint(v), err
+	}
+}
+
+// Uint64Parser returns a Parser for uint64s in the given format.
+func Uint64Parser(format NumberFormat) Parser[uint64] {
+	return func(s string) (uint64, error) {
+		return ParseUintFormat(s, format, Int64Bits)
+	}
+}
+
+// fieldNumberFormat reads the envBase and envNumberFormat tags of a field.
+func fieldNumberFormat(field reflect.StructField) (NumberFormat, error) {
+	var format NumberFormat
+	if tag, ok := field.Tag.Lookup("envBase"); ok {
+		base, err := strconv.Atoi(tag)
+		switch {
+		case err != nil || base == 1 || base < 0 || base > 36:
+			return format, fmt.Errorf("invalid envBase tag %q: must be 0 or from 2 to 36", tag)
+		case base == 0:
+			// As for strconv, base 0 means the base is taken from the prefix.
+			format.Prefixes = true
+		default:
+			format.Base = base
+		}
+	}
+	if tag := field.Tag.Get("envNumberFormat"); tag != "" {
+		for _, option := range strings.Split(tag, ",") {
+			switch strings.TrimSpace(option) {
+			case "prefixes":
+				format.Prefixes = true
+			case "si":
+				format.SI = true
+			case "iec":
+				format.IEC = true
+			default:
+				return format, fmt.Errorf("invalid envNumberFormat tag %q: unknown option %q", tag, option)
+			}
+		}
+	}
+	return format, nil
+}
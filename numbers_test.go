@@ -0,0 +1,180 @@
+package env
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseIntFormat(t *testing.T) {
+	prefixes := NumberFormat{Prefixes: true}
+	si := NumberFormat{SI: true}
+	iec := NumberFormat{IEC: true}
+	all := NumberFormat{Prefixes: true, SI: true, IEC: true}
+
+	tests := []struct {
+		in     string
+		format NumberFormat
+		want   int64
+	}{
+		{"42", NumberFormat{}, 42},
+		{"-42", NumberFormat{}, -42},
+		{"0x1F", prefixes, 31},
+		{"0o755", prefixes, 0o755},
+		{"0b101", prefixes, 5},
+		{"-0x10", prefixes, -16},
+		{"1_000_000", prefixes, 1000000},
+		{"0x_ff_ff", prefixes, 0xffff},
+		{"0755", prefixes, 755},
+		{"ff", NumberFormat{Base: 16}, 255},
+		{"0b1", NumberFormat{Base: 16}, 0xb1},
+		{"0x1F", NumberFormat{Base: 16, Prefixes: true}, 31},
+		{"0o17", NumberFormat{Base: 8, Prefixes: true}, 0o17},
+		{"ff", NumberFormat{Base: 16, Prefixes: true}, 255},
+		{"10k", si, 10000},
+		{"10K", si, 10000},
+		{"5M", si, 5000000},
+		{"-3G", si, -3000000000},
+		{"2Gi", iec, 2 << 30},
+		{"1Ki", iec, 1024},
+		{"1_5Ki", all, 15 * 1024},
+		{"0x10Mi", all, 16 << 20},
+		{"0x1E", all, 0x1E},
+		{"1E", all, 1e18},
+	}
+	for _, tt := range tests {
+		got, err := ParseIntFormat(tt.in, tt.format, Int64Bits)
+		if assert.NoError(t, err, tt.in) {
+			assert.Equal(t, tt.want, got, tt.in)
+		}
+	}
+}
+
+func TestParseIntFormatErrors(t *testing.T) {
+	tests := []struct {
+		in     string
+		format NumberFormat
+		err    error
+	}{
+		{"0x1F", NumberFormat{}, strconv.ErrSyntax},
+		{"1_000", NumberFormat{}, strconv.ErrSyntax},
+		{"10k", NumberFormat{}, strconv.ErrSyntax},
+		{"2Gi", NumberFormat{SI: true}, strconv.ErrSyntax},
+		{"2G", NumberFormat{IEC: true}, strconv.ErrSyntax},
+		{"_1000", NumberFormat{Prefixes: true}, strconv.ErrSyntax},
+		{"1__000", NumberFormat{Prefixes: true}, strconv.ErrSyntax},
+		{"1000_", NumberFormat{Prefixes: true}, strconv.ErrSyntax},
+		{"0b1", NumberFormat{Base: 16, Prefixes: true}, strconv.ErrSyntax},
+		{"0x1F", NumberFormat{Base: 10, Prefixes: true}, strconv.ErrSyntax},
+		{"-0o7", NumberFormat{Base: 2, Prefixes: true}, strconv.ErrSyntax},
+		{"k", NumberFormat{SI: true}, strconv.ErrSyntax},
+		{"10E", NumberFormat{SI: true}, strconv.ErrRange},
+		{"-9Ei", NumberFormat{IEC: true}, strconv.ErrRange},
+	}
+	for _, tt := range tests {
+		_, err := ParseIntFormat(tt.in, tt.format, Int64Bits)
+		assert.ErrorIs(t, err, tt.err, tt.in)
+		numErr, ok := err.(*strconv.NumError)
+		if assert.True(t, ok, tt.in) {
+			assert.Equal(t, tt.in, numErr.Num)
+			assert.Equal(t, "ParseInt", numErr.Func)
+		}
+	}
+
+	_, err := ParseIntFormat("3G", NumberFormat{SI: true}, Int32Bits)
+	assert.ErrorIs(t, err, strconv.ErrRange)
+}
+
+func TestParseUintFormat(t *testing.T) {
+	got, err := ParseUintFormat("16Ei", NumberFormat{IEC: true}, Int64Bits)
+	assert.ErrorIs(t, err, strconv.ErrRange)
+	assert.Zero(t, got)
+
+	got, err = ParseUintFormat("15Ei", NumberFormat{IEC: true}, Int64Bits)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(15)<<60, got)
+
+	got, err = ParseUintFormat("4Gi", NumberFormat{IEC: true}, Int32Bits)
+	assert.ErrorIs(t, err, strconv.ErrRange)
+	assert.Zero(t, got)
+
+	_, err = ParseUintFormat("-1k", NumberFormat{SI: true}, Int64Bits)
+	assert.ErrorIs(t, err, strconv.ErrSyntax)
+}
+
+func TestNumberFormatParsers(t *testing.T) {
+	SetForTest(t, "NUMBER_MODE", "0o644")
+	SetForTest(t, "NUMBER_LIMITS", "1k,2Ki")
+
+	mode, err := GetParsed("NUMBER_MODE", IntParser(NumberFormat{Prefixes: true}))
+	assert.NoError(t, err)
+	assert.Equal(t, 0o644, mode)
+
+	_, err = GetInt("NUMBER_MODE")
+	assert.Error(t, err, "the default parsers stay decimal")
+
+	limits, err := GetSlice("NUMBER_LIMITS", ",", Uint64Parser(NumberFormat{SI: true, IEC: true}))
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{1000, 2048}, limits)
+}
+
+func TestNumberFormatTags(t *testing.T) {
+	type config struct {
+		Mode    int      `env:"NUMBER_TAG_MODE" envNumberFormat:"prefixes"`
+		Mask    uint64   `env:"NUMBER_TAG_MASK" envBase:"16"`
+		Auto    uint     `env:"NUMBER_TAG_AUTO" envBase:"0"`
+		Bytes   int64    `env:"NUMBER_TAG_BYTES" envNumberFormat:"si, iec"`
+		Limits  []int    `env:"NUMBER_TAG_LIMITS" envNumberFormat:"si"`
+		Plain   int      `env:"NUMBER_TAG_PLAIN"`
+		Offsets []uint64 `env:"NUMBER_TAG_OFFSETS" envBase:"16"`
+	}
+	SetForTest(t, "NUMBER_TAG_MODE", "0o755")
+	SetForTest(t, "NUMBER_TAG_MASK", "ff00")
+	SetForTest(t, "NUMBER_TAG_AUTO", "0b1010")
+	SetForTest(t, "NUMBER_TAG_BYTES", "2Gi")
+	SetForTest(t, "NUMBER_TAG_LIMITS", "10k,5M")
+	SetForTest(t, "NUMBER_TAG_PLAIN", "0100")
+	SetForTest(t, "NUMBER_TAG_OFFSETS", "a,1f")
+
+	var cfg config
+	assert.NoError(t, Parse(&cfg))
+	assert.Equal(t, config{
+		Mode:    0o755,
+		Mask:    0xff00,
+		Auto:    10,
+		Bytes:   2 << 30,
+		Limits:  []int{10000, 5000000},
+		Plain:   100,
+		Offsets: []uint64{10, 31},
+	}, cfg)
+}
+
+func TestNumberFormatTagErrors(t *testing.T) {
+	SetForTest(t, "NUMBER_TAG_BAD", "1")
+
+	var badBase struct {
+		Value int `env:"NUMBER_TAG_BAD" envBase:"37"`
+	}
+	assert.ErrorContains(t, Parse(&badBase), `invalid envBase tag "37"`)
+
+	var badFormat struct {
+		Value int `env:"NUMBER_TAG_BAD" envNumberFormat:"hex"`
+	}
+	assert.ErrorContains(t, Parse(&badFormat), `invalid envNumberFormat tag "hex": unknown option "hex"`)
+
+	var suffixNotAllowed struct {
+		Value int `env:"NUMBER_TAG_SUFFIX" envNumberFormat:"si"`
+	}
+	SetForTest(t, "NUMBER_TAG_SUFFIX", "1Ki")
+	assert.Error(t, Parse(&suffixNotAllowed))
+
+	var conflictingPrefix struct {
+		Value uint64 `env:"NUMBER_TAG_HEX" envBase:"16" envNumberFormat:"prefixes"`
+	}
+	SetForTest(t, "NUMBER_TAG_HEX", "0xb1")
+	assert.NoError(t, Parse(&conflictingPrefix))
+	assert.Equal(t, uint64(0xb1), conflictingPrefix.Value)
+	SetForTest(t, "NUMBER_TAG_HEX", "0b1")
+	assert.ErrorContains(t, Parse(&conflictingPrefix), `parsing "0b1": invalid syntax`)
+}
@@ -0,0 +1,367 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Registry is a set of declared environment variables, in the way a
+// flag.FlagSet is a set of declared flags. Each declaration returns a typed
+// handle, and the registry can validate every variable at once, print usage
+// and describe its variables to the same tools as GetAllVars:
+//
+//	var (
+//		port    = env.Int("PORT", 8080, "HTTP listen port")
+//		dbURL   = env.String("DATABASE_URL", "", "Postgres connection string").Required()
+//		timeout = env.Duration("TIMEOUT", 5*time.Second, "request timeout")
+//	)
+//
+//	func main() {
+//		if err := env.ParseVars(); err != nil {
+//			env.PrintDefaults()
+//			log.Fatal(err)
+//		}
+//		listen(port.Get())
+//	}
+//
+// A Registry is safe for concurrent use.
+type Registry struct {
+	mu     sync.Mutex
+	env    *Environment
+	vars   []registeredVar
+	names  map[string]bool
+	output io.Writer
+}
+
+// registeredVar is the type-independent part of a Var.
+type registeredVar interface {
+	info() VarInfo
+	usage() string
+	load() error
+}
+
+var defaultRegistry = NewRegistry(nil)
+
+// DefaultRegistry returns the Registry used by the package-level String, Int,
+// Bool, Duration, ParseVars, PrintDefaults and Vars functions. It reads
+// DefaultEnvironment.
+func DefaultRegistry() *Registry {
+	return defaultRegistry
+}
+
+// NewRegistry returns an empty Registry whose variables are read from e, or
+// from DefaultEnvironment if e is nil.
+func NewRegistry(e *Environment) *Registry {
+	return &Registry{env: e, names: make(map[string]bool)}
+}
+
+// environment returns the Environment the registry reads.
+func (r *Registry) environment() *Environment {
+	if r.env == nil {
+		return defaultEnvironment
+	}
+	return r.env
+}
+
+// SetOutput sets where PrintDefaults writes. A nil w means os.Stderr.
+func (r *Registry) SetOutput(w io.Writer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.output = w
+}
+
+// Var is a declared environment variable of type T. Its value is read when
+// the registry's Parse method runs; before that, Get reads the environment
+// on every call.
+type Var[T any] struct {
+	reg          *Registry
+	name         string
+	description  string
+	defaultValue T
+	parser       Parser[T]
+
+	mu       sync.RWMutex
+	required bool
+	parsed   bool
+	value    T
+}
+
+// Define declares a variable of any type that parser can read, with a
+// default used when it is not set. It panics if name is already declared in
+// r, as flag does.
+func Define[T any](r *Registry, name string, defaultValue T, usage string, parser Parser[T]) *Var[T] {
+	v := &Var[T]{reg: r, name: name, description: usage, defaultValue: defaultValue, parser: parser}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.names[name] {
+		panic(fmt.Sprintf("env: variable %s declared twice", name))
+	}
+	r.names[name] = true
+	r.vars = append(r.vars, v)
+	return v
+}
+
+// Required marks v as required: Parse reports an error if it is not set. It
+// returns v so that it can follow a declaration.
+func (v *Var[T]) Required() *Var[T] {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.required = true
+	return v
+}
+
+// Name returns the name of the environment variable.
+func (v *Var[T]) Name() string {
+	return v.name
+}
+
+// Get returns the value of the variable, or its default if it is not set or
+// invalid.
+func (v *Var[T]) Get() T {
+	v.mu.RLock()
+	parsed, value := v.parsed, v.value
+	v.mu.RUnlock()
+	if parsed {
+		return value
+	}
+
+	value, err := GetParsedFrom(v.reg.environment(), v.name, v.parser)
+	if err != nil {
+		return v.defaultValue
+	}
+	return value
+}
+
+func (v *Var[T]) load() error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	value, err := GetParsedFrom(v.reg.environment(), v.name, v.parser)
+	switch {
+	case err == nil:
+	case v.required && isNotSet(err):
+		return fmt.Errorf("env var %s was missing and is required", v.name)
+	case isNotSet(err):
+		value = v.defaultValue
+	default:
+		return err
+	}
+	v.value, v.parsed = value, true
+	return nil
+}
+
+func (v *Var[T]) info() VarInfo {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	info := VarInfo{
+		Name:        v.name,
+		Required:    v.required,
+		Type:        typeName[T](),
+		Description: v.description,
+	}
+	// A required variable never falls back to its default, so it has none.
+	if !v.required {
+		info.HasDefault = true
+		if !v.defaultIsNil() {
+			info.Default = fmt.Sprint(v.defaultValue)
+		}
+	}
+	return info
+}
+
+// defaultIsZero reports whether the default is the zero value of T, which
+// PrintDefaults leaves out as flag does.
+func (v *Var[T]) defaultIsZero() bool {
+	return reflect.ValueOf(&v.defaultValue).Elem().IsZero()
+}
+
+// defaultIsNil reports whether the default is a nil pointer, map, slice or
+// other nillable value, which is described as an empty default.
+func (v *Var[T]) defaultIsNil() bool {
+	value := reflect.ValueOf(&v.defaultValue).Elem()
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		return value.IsNil()
+	}
+	return false
+}
+
+func (v *Var[T]) usage() string {
+	info := v.info()
+	s := fmt.Sprintf("  %s %s", info.Name, info.Type)
+	if info.Required {
+		s += " (required)"
+	}
+	s += "\n    \t" + info.Description
+	if info.HasDefault && !v.defaultIsZero() {
+		if info.Type == "string" {
+			s += fmt.Sprintf(" (default %q)", info.Default)
+		} else {
+			s += fmt.Sprintf(" (default %s)", info.Default)
+		}
+	}
+	return s + "\n"
+}
+
+// isNotSet reports whether err came from an unset variable.
+func isNotSet(err error) bool {
+	return errors.Is(err, ErrNotSet)
+}
+
+// registered returns a snapshot of r's variables in declaration order.
+func (r *Registry) registered() []registeredVar {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]registeredVar(nil), r.vars...)
+}
+
+// Parse reads every declared variable. Missing required variables and invalid
+// values are all reported, as ParseErrors, rather than stopping at the first.
+// Variables that failed keep reading the environment in Get.
+func (r *Registry) Parse() error {
+	var errs ParseErrors
+	for _, v := range r.registered() {
+		if err := v.load(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// PrintDefaults writes a usage message listing every declared variable, in
+// declaration order, in the style of flag.PrintDefaults.
+func (r *Registry) PrintDefaults() {
+	r.mu.Lock()
+	w := r.output
+	r.mu.Unlock()
+	if w == nil {
+		w = os.Stderr
+	}
+
+	for _, v := range r.registered() {
+		fmt.Fprint(w, v.usage())
+	}
+}
+
+// Vars describes every declared variable, in declaration order, in the same
+// form as GetAllVars, so that the same documentation and .env generators can
+// use either.
+func (r *Registry) Vars() []VarInfo {
+	registered := r.registered()
+	vars := make([]VarInfo, 0, len(registered))
+	for _, v := range registered {
+		vars = append(vars, v.info())
+	}
+	return vars
+}
+
+// String declares a string variable in r.
+func (r *Registry) String(name, defaultValue, usage string) *Var[string] {
+	return Define(r, name, defaultValue, usage, ParseString)
+}
+
+// Int declares an int variable in r.
+func (r *Registry) Int(name string, defaultValue int, usage string) *Var[int] {
+	return Define(r, name, defaultValue, usage, ParseInt)
+}
+
+// Int64 declares an int64 variable in r.
+func (r *Registry) Int64(name string, defaultValue int64, usage string) *Var[int64] {
+	return Define(r, name, defaultValue, usage, ParseInt64)
+}
+
+// Uint declares a uint variable in r.
+func (r *Registry) Uint(name string, defaultValue uint, usage string) *Var[uint] {
+	return Define(r, name, defaultValue, usage, ParseUint)
+}
+
+// Uint64 declares a uint64 variable in r.
+func (r *Registry) Uint64(name string, defaultValue uint64, usage string) *Var[uint64] {
+	return Define(r, name, defaultValue, usage, ParseUint64)
+}
+
+// Float64 declares a float64 variable in r.
+func (r *Registry) Float64(name string, defaultValue float64, usage string) *Var[float64] {
+	return Define(r, name, defaultValue, usage, ParseFloat64)
+}
+
+// Bool declares a bool variable in r.
+func (r *Registry) Bool(name string, defaultValue bool, usage string) *Var[bool] {
+	return Define(r, name, defaultValue, usage, ParseBool)
+}
+
+// Duration declares a time.Duration variable in r.
+func (r *Registry) Duration(name string, defaultValue time.Duration, usage string) *Var[time.Duration] {
+	return Define(r, name, defaultValue, usage, ParseDuration)
+}
+
+// String declares a string variable in DefaultRegistry.
+func String(name, defaultValue, usage string) *Var[string] {
+	return defaultRegistry.String(name, defaultValue, usage)
+}
+
+// Int declares an int variable in DefaultRegistry.
+func Int(name string, defaultValue int, usage string) *Var[int] {
+	return defaultRegistry.Int(name, defaultValue, usage)
+}
+
+// Int64 declares an int64 variable in DefaultRegistry.
+func Int64(name string, defaultValue int64, usage string) *Var[int64] {
+	return defaultRegistry.Int64(name, defaultValue, usage)
+}
+
+// Uint declares a uint variable in DefaultRegistry.
+func Uint(name string, defaultValue uint, usage string) *Var[uint] {
+	return defaultRegistry.Uint(name, defaultValue, usage)
+}
+
+// Uint64 declares a uint64 variable in DefaultRegistry.
+func Uint64(name string, defaultValue uint64, usage string) *Var[uint64] {
+	return defaultRegistry.Uint64(name, defaultValue, usage)
+}
+
+// Float64 declares a float64 variable in DefaultRegistry.
+func Float64(name string, defaultValue float64, usage string) *Var[float64] {
+	return defaultRegistry.Float64(name, defaultValue, usage)
+}
+
+// Bool declares a bool variable in DefaultRegistry.
+func Bool(name string, defaultValue bool, usage string) *Var[bool] {
+	return defaultRegistry.Bool(name, defaultValue, usage)
+}
+
+// Duration declares a time.Duration variable in DefaultRegistry.
+func Duration(name string, defaultValue time.Duration, usage string) *Var[time.Duration] {
+	return defaultRegistry.Duration(name, defaultValue, usage)
+}
+
+// ParseVars reads every variable declared in DefaultRegistry. See
+// Registry.Parse.
+func ParseVars() error {
+	return defaultRegistry.Parse()
+}
+
+// PrintDefaults writes a usage message for the variables declared in
+// DefaultRegistry to standard error.
+func PrintDefaults() {
+	defaultRegistry.PrintDefaults()
+}
+
+// Vars describes the variables declared in DefaultRegistry.
+func Vars() []VarInfo {
+	return defaultRegistry.Vars()
+}
@@ -0,0 +1,138 @@
+package env
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistryParse(t *testing.T) {
+	t.Parallel()
+	e := NewEnvironment(MapSource(map[string]string{
+		"REG_PORT":    "9090",
+		"REG_DEBUG":   "true",
+		"REG_TIMEOUT": "2s",
+	}))
+	r := NewRegistry(e)
+	port := r.Int("REG_PORT", 8080, "HTTP listen port")
+	debug := r.Bool("REG_DEBUG", false, "enable debug logging")
+	timeout := r.Duration("REG_TIMEOUT", time.Second, "request timeout")
+	name := r.String("REG_NAME", "api", "service name")
+
+	assert.NoError(t, r.Parse())
+	assert.Equal(t, 9090, port.Get())
+	assert.True(t, debug.Get())
+	assert.Equal(t, 2*time.Second, timeout.Get())
+	assert.Equal(t, "api", name.Get())
+	assert.Equal(t, "REG_PORT", port.Name())
+
+	// Parse takes a snapshot; later changes need another Parse.
+	assert.NoError(t, e.Set("REG_PORT", "7070"))
+	assert.Equal(t, 9090, port.Get())
+	assert.NoError(t, r.Parse())
+	assert.Equal(t, 7070, port.Get())
+}
+
+func TestRegistryGetBeforeParse(t *testing.T) {
+	t.Parallel()
+	e := NewEnvironment(MapSource(map[string]string{"REG_LIVE": "1", "REG_BAD": "x"}))
+	r := NewRegistry(e)
+	live := r.Int("REG_LIVE", 5, "")
+	bad := r.Int("REG_BAD", 5, "")
+
+	assert.Equal(t, 1, live.Get())
+	assert.Equal(t, 5, bad.Get())
+	assert.NoError(t, e.Set("REG_LIVE", "2"))
+	assert.Equal(t, 2, live.Get())
+}
+
+func TestRegistryParseErrors(t *testing.T) {
+	t.Parallel()
+	e := NewEnvironment(MapSource(map[string]string{"REG_PORT": "http", "REG_RATIO": "half"}))
+	r := NewRegistry(e)
+	r.Int("REG_PORT", 8080, "HTTP listen port")
+	r.String("REG_DATABASE_URL", "", "Postgres connection string").Required()
+	r.Float64("REG_RATIO", 0.5, "sampling ratio")
+	r.Uint("REG_WORKERS", 4, "worker count")
+
+	err := r.Parse()
+	var errs ParseErrors
+	if assert.True(t, errors.As(err, &errs)) && assert.Len(t, errs, 3) {
+		var valueErr *ValueError
+		assert.ErrorAs(t, errs[0], &valueErr)
+		assert.Equal(t, "REG_PORT", valueErr.Key)
+		assert.EqualError(t, errs[1], "env var REG_DATABASE_URL was missing and is required")
+		assert.ErrorAs(t, errs[2], &valueErr)
+		assert.Equal(t, "REG_RATIO", valueErr.Key)
+	}
+}
+
+func TestRegistryDefineTwicePanics(t *testing.T) {
+	t.Parallel()
+	r := NewRegistry(NewEnvironment())
+	r.Int("REG_TWICE", 1, "")
+	assert.PanicsWithValue(t, "env: variable REG_TWICE declared twice", func() {
+		r.String("REG_TWICE", "", "")
+	})
+}
+
+func TestRegistryPrintDefaults(t *testing.T) {
+	t.Parallel()
+	r := NewRegistry(NewEnvironment())
+	r.Int("REG_PORT", 8080, "HTTP listen port")
+	r.String("REG_NAME", "api", "service name")
+	r.String("REG_DATABASE_URL", "", "Postgres connection string").Required()
+	r.Bool("REG_DEBUG", false, "enable debug logging")
+	r.Bool("REG_CACHE", true, "enable caching")
+	r.Duration("REG_GRACE", 0, "shutdown grace period")
+
+	var sb strings.Builder
+	r.SetOutput(&sb)
+	r.PrintDefaults()
+	assert.Equal(t, `  REG_PORT int
+    	HTTP listen port (default 8080)
+  REG_NAME string
+    	service name (default "api")
+  REG_DATABASE_URL string (required)
+    	Postgres connection string
+  REG_DEBUG bool
+    	enable debug logging
+  REG_CACHE bool
+    	enable caching (default true)
+  REG_GRACE time.Duration
+    	shutdown grace period
+`, sb.String())
+
+	for _, info := range r.Vars() {
+		assert.Equal(t, !info.Required, info.HasDefault, info.Name)
+	}
+}
+
+func TestRegistryVars(t *testing.T) {
+	t.Parallel()
+	r := NewRegistry(NewEnvironment())
+	r.Duration("REG_TIMEOUT", 5*time.Second, "request timeout")
+	r.Int64("REG_LIMIT", 0, "row limit").Required()
+	r.Int("REG_RETRIES", 0, "retry count")
+	Define(r, "REG_URL", nil, "upstream URL", ParseURL)
+
+	assert.Equal(t, []VarInfo{
+		{Name: "REG_TIMEOUT", Type: "time.Duration", Default: "5s", HasDefault: true, Description: "request timeout"},
+		{Name: "REG_LIMIT", Type: "int64", Required: true, Description: "row limit"},
+		{Name: "REG_RETRIES", Type: "int", Default: "0", HasDefault: true, Description: "retry count"},
+		{Name: "REG_URL", Type: "*url.URL", HasDefault: true, Description: "upstream URL"},
+	}, r.Vars())
+}
+
+func TestDefaultRegistry(t *testing.T) {
+	SetForTest(t, "DEFAULT_REGISTRY_WORKERS", "12")
+	workers := Uint64("DEFAULT_REGISTRY_WORKERS", 4, "worker count")
+
+	assert.NoError(t, ParseVars())
+	assert.Equal(t, uint64(12), workers.Get())
+	assert.Same(t, defaultRegistry, DefaultRegistry())
+	assert.Contains(t, Vars(), VarInfo{Name: "DEFAULT_REGISTRY_WORKERS", Type: "uint64", Default: "4", HasDefault: true, Description: "worker count"})
+}
@@ -0,0 +1,272 @@
+package env
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+)
+
+// SecretKeyPattern matches the names of variables whose values are secret.
+// Values of matching variables are masked in load reports.
+var SecretKeyPattern = regexp.MustCompile(`(?i)(SECRET|PASSWORD|PASSWD|TOKEN|KEY|CREDENTIAL|PRIVATE|AUTH)`)
+
+// maskedValue replaces secret values in reports.
+const maskedValue = "****"
+
+// maskValue returns value, or a mask if key matches SecretKeyPattern.
+func maskValue(key, value string) string {
+	if value != "" && SecretKeyPattern != nil && SecretKeyPattern.MatchString(key) {
+		return maskedValue
+	}
+	return value
+}
+
+// LoadAction describes what a load did with one key from one file.
+type LoadAction int
+
+const (
+	// ActionApplied means the variable was not set and the file set it.
+	ActionApplied LoadAction = iota
+	// ActionSkipped means the variable was already set, so Load left it alone.
+	ActionSkipped
+	// ActionOverridden means the variable was already set and Overload replaced it.
+	ActionOverridden
+)
+
+func (a LoadAction) String() string {
+	switch a {
+	case ActionApplied:
+		return "applied"
+	case ActionSkipped:
+		return "skipped"
+	case ActionOverridden:
+		return "overridden"
+	}
+	return fmt.Sprintf("LoadAction(%d)", int(a))
+}
+
+// KeyReport describes what happened to one key from one file.
+type KeyReport struct {
+	Key    string
+	File   string
+	Action LoadAction
+	// SetBy names the file that set the variable before this file was
+	// considered, for skipped and overridden keys. It is empty when the value
+	// came from the process environment or an unknown source.
+	SetBy string
+	// Previous is the value the variable had before this file was considered,
+	// masked if the key matches SecretKeyPattern.
+	Previous string
+}
+
+func (k KeyReport) String() string {
+	switch k.Action {
+	case ActionSkipped:
+		return fmt.Sprintf("%s: %s skipped, already set by %s to %q", k.File, k.Key, k.setBy(), k.Previous)
+	case ActionOverridden:
+		return fmt.Sprintf("%s: %s overrode %q set by %s", k.File, k.Key, k.Previous, k.setBy())
+	}
+	return fmt.Sprintf("%s: %s %s", k.File, k.Key, k.Action)
+}
+
+func (k KeyReport) setBy() string {
+	if k.SetBy == "" {
+		return "the environment"
+	}
+	return k.SetBy
+}
+
+// LoadReport lists what LoadWithReport or OverloadWithReport did with every
+// key of every file, in file order and then key order.
+type LoadReport struct {
+	Keys []KeyReport
+}
+
+// Skipped returns the entries for keys that were not applied because the
+// variable was already set.
+func (r *LoadReport) Skipped() []KeyReport {
+	var skipped []KeyReport
+	for _, k := range r.Keys {
+		if k.Action == ActionSkipped {
+			skipped = append(skipped, k)
+		}
+	}
+	return skipped
+}
+
+// String formats the report as an aligned table, one key per line.
+func (r *LoadReport) String() string {
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 4, 2, ' ', 0)
+	for _, k := range r.Keys {
+		detail := ""
+		if k.Action != ActionApplied {
+			detail = fmt.Sprintf("was %q, set by %s", k.Previous, k.setBy())
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", k.File, k.Key, k.Action, detail)
+	}
+	_ = w.Flush()
+	return sb.String()
+}
+
+// LoadWithReport behaves like Load and also reports, for every key of every
+// file, whether it was applied or skipped because the variable was already
+// set, and by what. Use it to find out why an edit to a .env file has no
+// effect:
+//
+//	report, err := env.LoadWithReport(".env", ".env.local")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, k := range report.Skipped() {
+//		log.Println(k)
+//	}
+//
+// Keys are considered file by file, so a key in a later file is reported as
+// skipped when an earlier file set it. On error nothing is applied and the
+// report is nil.
+func LoadWithReport(filenames ...string) (*LoadReport, error) {
+	return loadWithReport(readFile, filenames, false)
+}
+
+// OverloadWithReport behaves like Overload and also reports, for every key of
+// every file, whether it was applied or overrode an existing value, and what
+// set that value.
+func OverloadWithReport(filenames ...string) (*LoadReport, error) {
+	return loadWithReport(readFile, filenames, true)
+}
+
+func loadWithReport(read fileReader, filenames []string, overload bool) (*LoadReport, error) {
+	filenames = filenamesOrDefault(filenames)
+	envMaps := make([]map[string]string, len(filenames))
+	for i, filename := range filenames {
+		envMap, err := read(filename)
+		if err != nil {
+			return nil, err
+		}
+		envMaps[i] = envMap
+	}
+
+	type state struct {
+		value, setBy string
+		set          bool
+	}
+	current := make(map[string]state)
+	merged := make(map[string]string)
+	sources := make(map[string]string)
+	report := &LoadReport{}
+
+	for i, filename := range filenames {
+		keys := make([]string, 0, len(envMaps[i]))
+		for key := range envMaps[i] {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			st, seen := current[key]
+			if !seen {
+				st.value, st.set = os.LookupEnv(key)
+				st.setBy = sourceOf(key, st.value)
+			}
+
+			entry := KeyReport{Key: key, File: filename}
+			switch {
+			case !st.set:
+				entry.Action = ActionApplied
+			case overload:
+				entry.Action = ActionOverridden
+			default:
+				entry.Action = ActionSkipped
+			}
+			if entry.Action != ActionApplied {
+				entry.SetBy = st.setBy
+				entry.Previous = maskValue(key, st.value)
+			}
+			report.Keys = append(report.Keys, entry)
+
+			if entry.Action != ActionSkipped {
+				value := envMaps[i][key]
+				merged[key], sources[key] = value, filename
+				st = state{value: value, setBy: filename, set: true}
+			}
+			current[key] = st
+		}
+	}
+
+	if _, err := applyEnvMap(merged, sources, true); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// loadSource remembers which file set a variable, and a fingerprint of the
+// value, so that reports can tell who set a variable as long as it still has
+// that value. The value itself is not kept, as it may be a secret.
+type loadSource struct {
+	file        string
+	fingerprint [sha256.Size]byte
+}
+
+var (
+	loadSourcesMu sync.Mutex
+	loadSources   = make(map[string]loadSource)
+	// fingerprintKey keys the fingerprints, so that they can't be matched
+	// against guessed values outside the process.
+	fingerprintKey []byte
+)
+
+// fingerprint returns a keyed hash of value. loadSourcesMu must be held.
+func fingerprint(value string) [sha256.Size]byte {
+	if fingerprintKey == nil {
+		fingerprintKey = make([]byte, sha256.Size)
+		if _, err := rand.Read(fingerprintKey); err != nil {
+			panic(fmt.Sprintf("env: generating fingerprint key: %v", err))
+		}
+	}
+	var sum [sha256.Size]byte
+	mac := hmac.New(sha256.New, fingerprintKey)
+	mac.Write([]byte(value))
+	copy(sum[:], mac.Sum(nil))
+	return sum
+}
+
+// setSource records that file set key to value and returns the previous record.
+func setSource(key, file, value string) loadSource {
+	loadSourcesMu.Lock()
+	defer loadSourcesMu.Unlock()
+
+	previous := loadSources[key]
+	loadSources[key] = loadSource{file: file, fingerprint: fingerprint(value)}
+	return previous
+}
+
+// restoreSource puts back a record returned by setSource.
+func restoreSource(key string, previous loadSource) {
+	loadSourcesMu.Lock()
+	defer loadSourcesMu.Unlock()
+
+	if previous.file == "" {
+		delete(loadSources, key)
+	} else {
+		loadSources[key] = previous
+	}
+}
+
+// sourceOf returns the file that set key to value, if known.
+func sourceOf(key, value string) string {
+	loadSourcesMu.Lock()
+	defer loadSourcesMu.Unlock()
+
+	if s, ok := loadSources[key]; ok && s.fingerprint == fingerprint(value) {
+		return s.file
+	}
+	return ""
+}
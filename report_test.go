@@ -0,0 +1,120 @@
+package env
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadWithReport(t *testing.T) {
+	root := makeTree(t, map[string]string{
+		".env":       "REPORT_HOST=file\nREPORT_PORT=80\nREPORT_PASSWORD=from-file",
+		".env.local": "REPORT_PORT=8080\nREPORT_NEW=1",
+	})
+	base, local := filepath.Join(root, ".env"), filepath.Join(root, ".env.local")
+	UnsetForTest(t, "REPORT_PORT")
+	UnsetForTest(t, "REPORT_NEW")
+	SetForTest(t, "REPORT_HOST", "process")
+	SetForTest(t, "REPORT_PASSWORD", "hunter2")
+
+	report, err := LoadWithReport(base, local)
+	assert.NoError(t, err)
+	assert.Equal(t, []KeyReport{
+		{Key: "REPORT_HOST", File: base, Action: ActionSkipped, Previous: "process"},
+		{Key: "REPORT_PASSWORD", File: base, Action: ActionSkipped, Previous: "****"},
+		{Key: "REPORT_PORT", File: base, Action: ActionApplied},
+		{Key: "REPORT_NEW", File: local, Action: ActionApplied},
+		{Key: "REPORT_PORT", File: local, Action: ActionSkipped, SetBy: base, Previous: "80"},
+	}, report.Keys)
+	assert.Len(t, report.Skipped(), 3)
+
+	assert.Equal(t, "process", os.Getenv("REPORT_HOST"))
+	assert.Equal(t, "80", os.Getenv("REPORT_PORT"))
+	assert.Equal(t, "1", os.Getenv("REPORT_NEW"))
+
+	assert.Equal(t, local+": REPORT_PORT skipped, already set by "+base+` to "80"`, report.Keys[4].String())
+	assert.Equal(t, base+": REPORT_HOST skipped, already set by the environment to \"process\"", report.Keys[0].String())
+	assert.Contains(t, report.String(), "REPORT_NEW")
+
+	// a later load knows which file set a variable
+	report, err = LoadWithReport(local)
+	assert.NoError(t, err)
+	assert.Equal(t, base, report.Keys[1].SetBy)
+}
+
+func TestOverloadWithReport(t *testing.T) {
+	root := makeTree(t, map[string]string{
+		"a.env": "REPORT_VALUE=a\nREPORT_API_KEY=a-key",
+		"b.env": "REPORT_VALUE=b",
+	})
+	a, b := filepath.Join(root, "a.env"), filepath.Join(root, "b.env")
+	SetForTest(t, "REPORT_VALUE", "process")
+	UnsetForTest(t, "REPORT_API_KEY")
+
+	report, err := OverloadWithReport(a, b)
+	assert.NoError(t, err)
+	assert.Equal(t, []KeyReport{
+		{Key: "REPORT_API_KEY", File: a, Action: ActionApplied},
+		{Key: "REPORT_VALUE", File: a, Action: ActionOverridden, Previous: "process"},
+		{Key: "REPORT_VALUE", File: b, Action: ActionOverridden, SetBy: a, Previous: "a"},
+	}, report.Keys)
+	assert.Equal(t, "b", os.Getenv("REPORT_VALUE"))
+
+	// a value changed outside the package is no longer attributed to the file
+	SetForTest(t, "REPORT_VALUE", "changed")
+	report, err = OverloadWithReport(b)
+	assert.NoError(t, err)
+	assert.Equal(t, "", report.Keys[0].SetBy)
+
+	report, err = OverloadWithReport(a, filepath.Join(root, "missing.env"))
+	assert.Error(t, err)
+	assert.Nil(t, report)
+	assert.Equal(t, "b", os.Getenv("REPORT_VALUE"), "a failed load changes nothing")
+}
+
+func TestRestoreForgetsSource(t *testing.T) {
+	root := makeTree(t, map[string]string{".env": "REPORT_RESTORED=1"})
+	filename := filepath.Join(root, ".env")
+	UnsetForTest(t, "REPORT_RESTORED")
+
+	restore, err := LoadWithRestore(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, filename, sourceOf("REPORT_RESTORED", "1"))
+	assert.NoError(t, restore.Restore())
+	assert.Equal(t, "", sourceOf("REPORT_RESTORED", "1"))
+}
+
+func TestLoadSourcesKeepNoValues(t *testing.T) {
+	root := makeTree(t, map[string]string{".env": "REPORT_SECRET=hunter2"})
+	filename := filepath.Join(root, ".env")
+	UnsetForTest(t, "REPORT_SECRET")
+
+	restore, err := LoadWithRestore(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, filename, sourceOf("REPORT_SECRET", "hunter2"))
+	assert.Equal(t, "", sourceOf("REPORT_SECRET", "hunter3"), "a changed value has no known source")
+
+	loadSourcesMu.Lock()
+	record := loadSources["REPORT_SECRET"]
+	loadSourcesMu.Unlock()
+	assert.NotContains(t, fmt.Sprintf("%+v", record), "hunter2")
+
+	assert.NoError(t, restore.Restore())
+	loadSourcesMu.Lock()
+	_, kept := loadSources["REPORT_SECRET"]
+	loadSourcesMu.Unlock()
+	assert.False(t, kept, "Restore clears the record")
+}
+
+func TestMaskValue(t *testing.T) {
+	assert.Equal(t, "****", maskValue("DB_PASSWORD", "x"))
+	assert.Equal(t, "****", maskValue("github_token", "x"))
+	assert.Equal(t, "", maskValue("API_KEY", ""))
+	assert.Equal(t, "localhost", maskValue("DB_HOST", "localhost"))
+	assert.Equal(t, "LoadAction(9)", LoadAction(9).String())
+	assert.True(t, strings.HasPrefix(ActionOverridden.String(), "over"))
+}
@@ -0,0 +1,102 @@
+package env
+
+import (
+	"fmt"
+	"os"
+)
+
+// Restorer reverts the environment variables changed by LoadWithRestore or
+// OverloadWithRestore. Variables that were set before the load get their
+// previous value back; variables the load created are unset. Variables the
+// load left alone are not touched.
+type Restorer struct {
+	changes []envChange
+}
+
+// envChange records the state of a variable before a load changed it.
+type envChange struct {
+	key     string
+	value   string
+	existed bool
+	source  loadSource
+}
+
+// Keys returns the names of the variables the load changed and Restore has not
+// yet reverted, in sorted order.
+func (r *Restorer) Keys() []string {
+	if r == nil {
+		return nil
+	}
+	keys := make([]string, 0, len(r.changes))
+	for _, c := range r.changes {
+		keys = append(keys, c.key)
+	}
+	return keys
+}
+
+// Restore reverts every variable changed by the load. It is safe to call more
+// than once; calls after the first successful one do nothing. If a variable
+// can't be restored, the rest are still attempted and the first error is
+// returned. The variables that failed are kept, so calling Restore again
+// retries them.
+func (r *Restorer) Restore() error {
+	if r == nil {
+		return nil
+	}
+
+	var firstErr error
+	failed := make([]bool, len(r.changes))
+	for i := len(r.changes) - 1; i >= 0; i-- {
+		c := r.changes[i]
+		var err error
+		if c.existed {
+			err = os.Setenv(c.key, c.value)
+		} else {
+			err = os.Unsetenv(c.key)
+		}
+		if err != nil {
+			failed[i] = true
+			if firstErr == nil {
+				firstErr = fmt.Errorf("restoring %s: %w", c.key, err)
+			}
+			continue
+		}
+		restoreSource(c.key, c.source)
+	}
+
+	pending := r.changes[:0]
+	for i, c := range r.changes {
+		if failed[i] {
+			pending = append(pending, c)
+		}
+	}
+	if len(pending) == 0 {
+		pending = nil
+	}
+	r.changes = pending
+	return firstErr
+}
+
+// LoadWithRestore behaves like Load and also returns a Restorer that undoes
+// exactly the changes made by this call. It is useful in tests and for
+// temporarily applying a configuration:
+//
+//	restore, err := env.LoadWithRestore("testdata/test.env")
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	defer restore.Restore()
+//
+// On error the environment is unchanged and the Restorer is nil.
+func LoadWithRestore(filenames ...string) (*Restorer, error) {
+	return applyFiles(readFile, filenames, false)
+}
+
+// OverloadWithRestore behaves like Overload and also returns a Restorer that
+// undoes exactly the changes made by this call, putting back the values it
+// overrode.
+//
+// On error the environment is unchanged and the Restorer is nil.
+func OverloadWithRestore(filenames ...string) (*Restorer, error) {
+	return applyFiles(readFile, filenames, true)
+}
@@ -0,0 +1,114 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadIsTransactional(t *testing.T) {
+	root := makeTree(t, map[string]string{
+		"base.env": "TX_BASE=base",
+		"bad.env":  "TX_OTHER=other\nINVALID LINE",
+	})
+	UnsetForTest(t, "TX_BASE")
+	UnsetForTest(t, "TX_OTHER")
+
+	for name, load := range map[string]func(...string) error{"Load": Load, "Overload": Overload} {
+		err := load(filepath.Join(root, "base.env"), filepath.Join(root, "bad.env"))
+		assert.Error(t, err, name)
+		_, set := os.LookupEnv("TX_BASE")
+		assert.False(t, set, "%s applied base.env before failing", name)
+	}
+}
+
+func TestLoadPrecedenceAcrossFiles(t *testing.T) {
+	root := makeTree(t, map[string]string{
+		"first.env":  "TX_PRECEDENCE=first",
+		"second.env": "TX_PRECEDENCE=second",
+	})
+	first, second := filepath.Join(root, "first.env"), filepath.Join(root, "second.env")
+
+	UnsetForTest(t, "TX_PRECEDENCE")
+	assert.NoError(t, Load(first, second))
+	assert.Equal(t, "first", os.Getenv("TX_PRECEDENCE"))
+
+	UnsetForTest(t, "TX_PRECEDENCE")
+	assert.NoError(t, Overload(first, second))
+	assert.Equal(t, "second", os.Getenv("TX_PRECEDENCE"))
+}
+
+func TestLoadWithRestore(t *testing.T) {
+	root := makeTree(t, map[string]string{
+		".env": "TX_NEW=new\nTX_EXISTING=from-file",
+	})
+	filename := filepath.Join(root, ".env")
+	UnsetForTest(t, "TX_NEW")
+	SetForTest(t, "TX_EXISTING", "original")
+
+	restore, err := LoadWithRestore(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"TX_NEW"}, restore.Keys())
+	assert.Equal(t, "new", os.Getenv("TX_NEW"))
+	assert.Equal(t, "original", os.Getenv("TX_EXISTING"))
+
+	assert.NoError(t, restore.Restore())
+	_, set := os.LookupEnv("TX_NEW")
+	assert.False(t, set)
+	assert.Equal(t, "original", os.Getenv("TX_EXISTING"))
+}
+
+func TestOverloadWithRestore(t *testing.T) {
+	root := makeTree(t, map[string]string{
+		".env": "TX_NEW=new\nTX_EXISTING=from-file",
+	})
+	filename := filepath.Join(root, ".env")
+	UnsetForTest(t, "TX_NEW")
+	SetForTest(t, "TX_EXISTING", "original")
+	SetForTest(t, "TX_UNRELATED", "untouched")
+
+	restore, err := OverloadWithRestore(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"TX_EXISTING", "TX_NEW"}, restore.Keys())
+	assert.Equal(t, "from-file", os.Getenv("TX_EXISTING"))
+
+	assert.NoError(t, os.Setenv("TX_UNRELATED", "changed later"))
+	assert.NoError(t, restore.Restore())
+	assert.Equal(t, "original", os.Getenv("TX_EXISTING"))
+	_, set := os.LookupEnv("TX_NEW")
+	assert.False(t, set)
+	assert.Equal(t, "changed later", os.Getenv("TX_UNRELATED"))
+
+	// a second Restore does nothing
+	assert.NoError(t, os.Setenv("TX_EXISTING", "after"))
+	assert.NoError(t, restore.Restore())
+	assert.Equal(t, "after", os.Getenv("TX_EXISTING"))
+
+	restore, err = OverloadWithRestore(filepath.Join(root, "missing.env"))
+	assert.Error(t, err)
+	assert.Nil(t, restore)
+	assert.NoError(t, restore.Restore())
+}
+
+func TestRestoreKeepsFailures(t *testing.T) {
+	SetForTest(t, "TX_RESTORED", "loaded")
+	restore := &Restorer{changes: []envChange{
+		{key: "TX=INVALID", value: "original", existed: true},
+		{key: "TX_RESTORED"},
+	}}
+
+	assert.ErrorContains(t, restore.Restore(), "restoring TX=INVALID")
+	_, set := os.LookupEnv("TX_RESTORED")
+	assert.False(t, set)
+	assert.Equal(t, []string{"TX=INVALID"}, restore.Keys(), "a failed variable is kept for a retry")
+	assert.Error(t, restore.Restore())
+
+	restore.changes[0].key = "TX_RETRIED"
+	UnsetForTest(t, "TX_RETRIED")
+	assert.NoError(t, restore.Restore())
+	assert.Equal(t, "original", os.Getenv("TX_RETRIED"))
+	assert.Empty(t, restore.Keys())
+	assert.NoError(t, restore.Restore())
+}
@@ -0,0 +1,169 @@
+package env
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// Layouts for ParseTime and the envLayout tag that read a timestamp as an
+// integer number of seconds or milliseconds since the Unix epoch.
+const (
+	LayoutUnix      = "unix"
+	LayoutUnixMilli = "unixmilli"
+)
+
+// timeLayoutNames maps the names ParseTime accepts in place of a layout to
+// the time package layouts they stand for.
+var timeLayoutNames = map[string]string{
+	"ANSIC":       time.ANSIC,
+	"UnixDate":    time.UnixDate,
+	"RubyDate":    time.RubyDate,
+	"RFC822":      time.RFC822,
+	"RFC822Z":     time.RFC822Z,
+	"RFC850":      time.RFC850,
+	"RFC1123":     time.RFC1123,
+	"RFC1123Z":    time.RFC1123Z,
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"Kitchen":     time.Kitchen,
+	"DateTime":    "2006-01-02 15:04:05",
+	"DateOnly":    "2006-01-02",
+	"TimeOnly":    "15:04:05",
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	locationType = reflect.TypeOf((*time.Location)(nil))
+)
+
+// ParseTime parses s as a time.Time. The layout is a time package layout such
+// as "2006-01-02", the name of one such as "RFC3339" or "DateOnly", or one of
+// LayoutUnix and LayoutUnixMilli. An empty layout means RFC 3339. Errors name
+// the layout that was expected.
+func ParseTime(s, layout string) (time.Time, error) {
+	switch layout {
+	case LayoutUnix, LayoutUnixMilli:
+		n, err := strconv.ParseInt(s, DecimalBase, Int64Bits)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("cannot parse %q as time with layout %q: %w", s, layout, err)
+		}
+		if layout == LayoutUnix {
+			return time.Unix(n, 0), nil
+		}
+		return time.UnixMilli(n), nil
+	}
+
+	layout = resolveTimeLayout(layout)
+	t, err := time.Parse(layout, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("cannot parse %q as time with layout %q", s, layout)
+	}
+	return t, nil
+}
+
+// resolveTimeLayout returns the layout a name stands for, RFC 3339 for an
+// empty name, and any other layout unchanged.
+func resolveTimeLayout(layout string) string {
+	if layout == "" {
+		return time.RFC3339
+	}
+	if named, ok := timeLayoutNames[layout]; ok {
+		return named
+	}
+	return layout
+}
+
+// TimeParser returns a Parser that parses times with ParseTime and layout.
+func TimeParser(layout string) Parser[time.Time] {
+	return func(s string) (time.Time, error) {
+		return ParseTime(s, layout)
+	}
+}
+
+// ParseLocation parses a time zone name such as "UTC", "Local" or
+// "America/Los_Angeles" with time.LoadLocation.
+func ParseLocation(s string) (*time.Location, error) {
+	loc, err := time.LoadLocation(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone %q: %w", s, err)
+	}
+	return loc, nil
+}
+
+// fieldTimeParser reads the envLayout tag of a field.
+func fieldTimeParser(field reflect.StructField) Parser[time.Time] {
+	return TimeParser(field.Tag.Get("envLayout"))
+}
+
+// GetTime retrieves an environment variable and parses it as a time.Time with
+// the given layout; see ParseTime. Returns the parsed time and any parsing error.
+func GetTime(key, layout string) (time.Time, error) {
+	return defaultEnvironment.GetTime(key, layout)
+}
+
+// GetOrTime retrieves an environment variable and parses it as a time.Time with
+// the given layout. If the variable is not set or parsing fails, it returns the
+// default value.
+func GetOrTime(key, layout string, defaultValue time.Time) time.Time {
+	return defaultEnvironment.GetOrTime(key, layout, defaultValue)
+}
+
+// MustGetTime retrieves an environment variable and parses it as a time.Time
+// with the given layout. If the variable is not set or parsing fails, it panics.
+func MustGetTime(key, layout string) time.Time {
+	return defaultEnvironment.MustGetTime(key, layout)
+}
+
+// GetLocation retrieves an environment variable and loads it as a time zone
+// with time.LoadLocation. Returns the location and any loading error.
+func GetLocation(key string) (*time.Location, error) {
+	return defaultEnvironment.GetLocation(key)
+}
+
+// GetOrLocation retrieves an environment variable and loads it as a time zone.
+// If the variable is not set or loading fails, it returns the default value.
+func GetOrLocation(key string, defaultValue *time.Location) *time.Location {
+	return defaultEnvironment.GetOrLocation(key, defaultValue)
+}
+
+// MustGetLocation retrieves an environment variable and loads it as a time
+// zone. If the variable is not set or loading fails, it panics.
+func MustGetLocation(key string) *time.Location {
+	return defaultEnvironment.MustGetLocation(key)
+}
+
+// GetTime parses the value of key as a time.Time with layout.
+func (e *Environment) GetTime(key, layout string) (time.Time, error) {
+	return GetParsedFrom(e, key, TimeParser(layout))
+}
+
+// GetOrTime parses the value of key as a time.Time with layout, returning
+// defaultValue if it is not set or invalid.
+func (e *Environment) GetOrTime(key, layout string, defaultValue time.Time) time.Time {
+	return GetOrParsedFrom(e, key, defaultValue, TimeParser(layout))
+}
+
+// MustGetTime parses the value of key as a time.Time with layout, panicking
+// if it is not set or invalid.
+func (e *Environment) MustGetTime(key, layout string) time.Time {
+	return MustGetParsedFrom(e, key, TimeParser(layout), "time.Time")
+}
+
+// GetLocation loads the value of key as a time zone.
+func (e *Environment) GetLocation(key string) (*time.Location, error) {
+	return GetParsedFrom(e, key, ParseLocation)
+}
+
+// GetOrLocation loads the value of key as a time zone, returning defaultValue
+// if it is not set or invalid.
+func (e *Environment) GetOrLocation(key string, defaultValue *time.Location) *time.Location {
+	return GetOrParsedFrom(e, key, defaultValue, ParseLocation)
+}
+
+// MustGetLocation loads the value of key as a time zone, panicking if it is
+// not set or invalid.
+func (e *Environment) MustGetLocation(key string) *time.Location {
+	return MustGetParsedFrom(e, key, ParseLocation, "*time.Location")
+}
@@ -0,0 +1,142 @@
+package env
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTime(t *testing.T) {
+	want := time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		in, layout string
+		want       time.Time
+	}{
+		{"2024-03-15T10:30:00Z", "", want},
+		{"2024-03-15T10:30:00Z", "RFC3339", want},
+		{"2024-03-15T10:30:00.5Z", "RFC3339Nano", want.Add(500 * time.Millisecond)},
+		{"2024-03-15", "DateOnly", time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"2024-03-15 10:30:00", "DateTime", want},
+		{"15/03/2024 10:30", "02/01/2006 15:04", want},
+		{"1710498600", LayoutUnix, want},
+		{"1710498600500", LayoutUnixMilli, want.Add(500 * time.Millisecond)},
+		{"-1", LayoutUnix, time.Unix(-1, 0)},
+	}
+	for _, tt := range tests {
+		got, err := ParseTime(tt.in, tt.layout)
+		if assert.NoError(t, err, tt.in) {
+			assert.True(t, tt.want.Equal(got), "%s: got %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseTimeErrors(t *testing.T) {
+	_, err := ParseTime("2024-03-15", "")
+	assert.EqualError(t, err, `cannot parse "2024-03-15" as time with layout "2006-01-02T15:04:05Z07:00"`)
+
+	_, err = ParseTime("15/03/2024", "DateOnly")
+	assert.EqualError(t, err, `cannot parse "15/03/2024" as time with layout "2006-01-02"`)
+
+	_, err = ParseTime("2024-03-15T10:30:00Z", LayoutUnix)
+	assert.ErrorIs(t, err, strconv.ErrSyntax)
+	assert.ErrorContains(t, err, `cannot parse "2024-03-15T10:30:00Z" as time with layout "unix"`)
+
+	_, err = ParseTime("99999999999999999999", LayoutUnixMilli)
+	assert.ErrorIs(t, err, strconv.ErrRange)
+}
+
+func TestParseLocation(t *testing.T) {
+	loc, err := ParseLocation("UTC")
+	assert.NoError(t, err)
+	assert.Equal(t, time.UTC, loc)
+
+	loc, err = ParseLocation("America/Los_Angeles")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "America/Los_Angeles", loc.String())
+	}
+
+	_, err = ParseLocation("Mars/Olympus_Mons")
+	assert.ErrorContains(t, err, `invalid time zone "Mars/Olympus_Mons"`)
+}
+
+func TestTimeFuncs(t *testing.T) {
+	SetForTest(t, "TIME_START", "2024-03-15")
+	SetForTest(t, "TIME_ZONE", "Europe/Paris")
+	SetForTest(t, "BAD_TIME_ZONE", "Nowhere")
+	UnsetForTest(t, "ENV_NO_EXISTS")
+	start := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+
+	got, err := GetTime("TIME_START", "DateOnly")
+	assert.NoError(t, err)
+	assert.True(t, start.Equal(got))
+	assert.True(t, start.Equal(MustGetTime("TIME_START", "2006-01-02")))
+	assert.True(t, start.Equal(GetOrTime("TIME_START", "DateOnly", time.Time{})))
+
+	_, err = GetTime("TIME_START", "")
+	assert.Error(t, err)
+	assert.True(t, GetOrTime("TIME_START", "", time.Time{}).IsZero())
+	assert.PanicsWithValue(t, `environment variable "TIME_START" could not be converted to time.Time`, func() {
+		MustGetTime("TIME_START", LayoutUnix)
+	})
+	_, err = GetTime("ENV_NO_EXISTS", "")
+	assert.ErrorIs(t, err, ErrNotSet)
+
+	loc, err := GetLocation("TIME_ZONE")
+	assert.NoError(t, err)
+	assert.Equal(t, "Europe/Paris", loc.String())
+	assert.Equal(t, "Europe/Paris", MustGetLocation("TIME_ZONE").String())
+	assert.Equal(t, time.UTC, GetOrLocation("BAD_TIME_ZONE", time.UTC))
+	assert.Equal(t, time.UTC, GetOrLocation("ENV_NO_EXISTS", time.UTC))
+	assert.Panics(t, func() { MustGetLocation("BAD_TIME_ZONE") })
+}
+
+func TestTimeStructFields(t *testing.T) {
+	type config struct {
+		Start    time.Time        `env:"TIME_FIELD_START"`
+		Day      time.Time        `env:"TIME_FIELD_DAY" envLayout:"DateOnly"`
+		Epoch    time.Time        `env:"TIME_FIELD_EPOCH" envLayout:"unix"`
+		Default  time.Time        `env:"TIME_FIELD_DEFAULT" envLayout:"unixmilli" envDefault:"1000"`
+		Holidays []time.Time      `env:"TIME_FIELD_HOLIDAYS" envLayout:"2006-01-02"`
+		Zone     *time.Location   `env:"TIME_FIELD_ZONE"`
+		Zones    []*time.Location `env:"TIME_FIELD_ZONES" envSeparator:";"`
+	}
+	SetForTest(t, "TIME_FIELD_START", "2024-03-15T10:30:00+01:00")
+	SetForTest(t, "TIME_FIELD_DAY", "2024-03-15")
+	SetForTest(t, "TIME_FIELD_EPOCH", "1710498600")
+	UnsetForTest(t, "TIME_FIELD_DEFAULT")
+	SetForTest(t, "TIME_FIELD_HOLIDAYS", "2024-12-25,2025-01-01")
+	SetForTest(t, "TIME_FIELD_ZONE", "Asia/Tokyo")
+	SetForTest(t, "TIME_FIELD_ZONES", "UTC;America/New_York")
+
+	var cfg config
+	assert.NoError(t, Parse(&cfg))
+	assert.True(t, time.Date(2024, 3, 15, 9, 30, 0, 0, time.UTC).Equal(cfg.Start))
+	assert.True(t, time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC).Equal(cfg.Day))
+	assert.True(t, time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC).Equal(cfg.Epoch))
+	assert.True(t, time.Unix(1, 0).Equal(cfg.Default))
+	if assert.Len(t, cfg.Holidays, 2) {
+		assert.True(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC).Equal(cfg.Holidays[1]))
+	}
+	if assert.NotNil(t, cfg.Zone) {
+		assert.Equal(t, "Asia/Tokyo", cfg.Zone.String())
+	}
+	if assert.Len(t, cfg.Zones, 2) {
+		assert.Equal(t, time.UTC, cfg.Zones[0])
+		assert.Equal(t, "America/New_York", cfg.Zones[1].String())
+	}
+
+	// A location already set is replaced rather than parsed as a nested struct.
+	SetForTest(t, "TIME_FIELD_ZONE", "UTC")
+	assert.NoError(t, Parse(&cfg))
+	assert.Equal(t, time.UTC, cfg.Zone)
+
+	SetForTest(t, "TIME_FIELD_DAY", "15/03/2024")
+	SetForTest(t, "TIME_FIELD_HOLIDAYS", "2024-12-25,Christmas")
+	SetForTest(t, "TIME_FIELD_ZONES", "UTC;Nowhere")
+	err := Parse(&config{})
+	assert.ErrorContains(t, err, `cannot parse "15/03/2024" as time with layout "2006-01-02"`)
+	assert.ErrorContains(t, err, `element 1 ("Christmas")`)
+	assert.ErrorContains(t, err, `element 1 ("Nowhere"): invalid time zone "Nowhere"`)
+}
@@ -1,11 +1,13 @@
 package env
 
 import (
+	"errors"
 	"fmt"
 	"net/url"
 	"os"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -22,6 +24,55 @@ func isValidEnvVarKey(key string) bool {
 	return envVarNameRegex.MatchString(key)
 }
 
+// Lookuper looks up the value of an environment variable, reporting whether
+// it is set. It has the same contract as os.LookupEnv.
+type Lookuper interface {
+	Lookup(key string) (string, bool)
+}
+
+// LookupFunc adapts an ordinary function such as os.LookupEnv to a Lookuper.
+type LookupFunc func(key string) (string, bool)
+
+// Lookup calls f(key).
+func (f LookupFunc) Lookup(key string) (string, bool) {
+	return f(key)
+}
+
+// ErrNotSet is returned by the Get functions when the variable is not set.
+// Test for it with errors.Is.
+var ErrNotSet = errors.New("environment variable not set")
+
+// OnInvalidValue is an optional callback, such as for logging purposes. If not
+// nil, it's called when a GetOr function falls back to its default because the
+// variable is set to a value that can't be parsed. If it is nil, DebugLogger is
+// used instead. Environment.SetInvalidValueHandler overrides it for one
+// Environment.
+var OnInvalidValue func(err *ValueError)
+
+// ValueError is returned by the Get functions when a variable is set to a
+// value that can't be parsed as the requested type.
+type ValueError struct {
+	Key   string
+	Value string
+	Type  string
+	Err   error
+}
+
+// Error implements the error interface for ValueError. Values of variables
+// whose names match SecretKeyPattern are masked, and the parser's error, which
+// usually quotes the value, is left out.
+func (e *ValueError) Error() string {
+	if masked := maskValue(e.Key, e.Value); masked != e.Value {
+		return fmt.Sprintf("environment variable %q has an invalid %s value %q", e.Key, e.Type, masked)
+	}
+	return fmt.Sprintf("environment variable %q has an invalid %s value %q: %v", e.Key, e.Type, e.Value, e.Err)
+}
+
+// Unwrap returns the parser's error.
+func (e *ValueError) Unwrap() error {
+	return e.Err
+}
+
 // Generic types and functions for reducing code duplication
 
 // Parser is a function type that converts a string value to type T.
@@ -30,28 +81,22 @@ func isValidEnvVarKey(key string) bool {
 type Parser[T any] func(string) (T, error)
 
 // GetParsed retrieves an environment variable and parses it using the provided parser function.
-// It returns the parsed value and any parsing error. If the environment variable is not set,
-// the parser receives an empty string.
+// If the environment variable is not set, it returns an error wrapping ErrNotSet. If parsing
+// fails, it returns a *ValueError wrapping the parser's error.
 //
 // This is a generic function that can be used with any type that has a corresponding parser.
 // For common types, use the specific Get* functions which are more convenient.
 func GetParsed[T any](key string, parser Parser[T]) (T, error) {
-	value := os.Getenv(key)
-	return parser(value)
+	return GetParsedFrom(defaultEnvironment, key, parser)
 }
 
 // GetOrParsed retrieves an environment variable and parses it using the provided parser function.
 // If the environment variable is not set or parsing fails, it returns the default value.
 //
 // This function never returns an error; it falls back to the default value on any failure.
+// Falling back because of an invalid value is reported to OnInvalidValue.
 func GetOrParsed[T any](key string, defaultValue T, parser Parser[T]) T {
-	strValue, ok := os.LookupEnv(key)
-	if ok {
-		if value, err := parser(strValue); err == nil {
-			return value
-		}
-	}
-	return defaultValue
+	return GetOrParsedFrom(defaultEnvironment, key, defaultValue, parser)
 }
 
 // MustGetParsed retrieves an environment variable and parses it using the provided parser function.
@@ -60,21 +105,13 @@ func GetOrParsed[T any](key string, defaultValue T, parser Parser[T]) T {
 // The typeName parameter is used in panic messages to identify the expected type.
 // Use this function when the environment variable is required for the application to function.
 func MustGetParsed[T any](key string, parser Parser[T], typeName string) T {
-	strValue, ok := os.LookupEnv(key)
-	if ok {
-		if value, err := parser(strValue); err == nil {
-			return value
-		} else {
-			panic(fmt.Sprintf("environment variable \"%s\" could not be converted to %s", key, typeName))
-		}
-	}
-	panic(fmt.Sprintf("expected environment variable \"%s\" does not exist", key))
+	return MustGetParsedFrom(defaultEnvironment, key, parser, typeName)
 }
 
 // Type-specific parser functions
 var (
 	ParseBool = func(s string) (bool, error) {
-		return strconv.ParseBool(s)
+		return ParseBoolWith(s, currentBoolVocabulary())
 	}
 	ParseInt = func(s string) (int, error) {
 		v, err := strconv.ParseInt(s, DecimalBase, Int32Bits)
@@ -105,28 +142,130 @@ var (
 	}
 )
 
+// ParseString returns s unchanged. It is the element parser for string slices
+// and maps.
+var ParseString = func(s string) (string, error) {
+	return s, nil
+}
+
+// SliceParser returns a Parser that splits a value on sep and parses each
+// element with parser. The separator defaults to ",", as for struct fields
+// without an envSeparator tag, and an empty value is an empty slice. Errors
+// name the element that failed:
+//
+//	element 2 ("x"): strconv.ParseInt: parsing "x": invalid syntax
+func SliceParser[T any](sep string, parser Parser[T]) Parser[[]T] {
+	if sep == "" {
+		sep = ","
+	}
+	return func(s string) ([]T, error) {
+		if s == "" {
+			return nil, nil
+		}
+		return parseSlice(strings.Split(s, sep), parser)
+	}
+}
+
+// MapParser returns a Parser for values such as "a:1,b:2". Pairs are split on
+// sep, which defaults to ",", and keys from values on kvSep, which defaults to
+// ":". An empty value is an empty map, and later pairs replace earlier ones
+// with the same key. Errors name the pair that failed.
+func MapParser[K comparable, V any](sep, kvSep string, keyParser Parser[K], valueParser Parser[V]) Parser[map[K]V] {
+	if sep == "" {
+		sep = ","
+	}
+	if kvSep == "" {
+		kvSep = ":"
+	}
+	return func(s string) (map[K]V, error) {
+		m := make(map[K]V)
+		if s == "" {
+			return m, nil
+		}
+		for i, pair := range strings.Split(s, sep) {
+			k, v, ok := strings.Cut(pair, kvSep)
+			if !ok {
+				return nil, fmt.Errorf("element %d (%q): missing %q between key and value", i, pair, kvSep)
+			}
+			key, err := keyParser(k)
+			if err != nil {
+				return nil, fmt.Errorf("element %d (%q): key: %w", i, pair, err)
+			}
+			value, err := valueParser(v)
+			if err != nil {
+				return nil, fmt.Errorf("element %d (%q): value: %w", i, pair, err)
+			}
+			m[key] = value
+		}
+		return m, nil
+	}
+}
+
+// parseSlice parses every element of data, naming the element that failed.
+func parseSlice[T any](data []string, parser Parser[T]) ([]T, error) {
+	values := make([]T, 0, len(data))
+	for i, v := range data {
+		value, err := parser(v)
+		if err != nil {
+			return nil, fmt.Errorf("element %d (%q): %w", i, v, err)
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// GetSlice retrieves an environment variable, splits it on sep and parses each element
+// using the provided parser function. An empty variable is an empty slice.
+//
+// For example, with PORTS=80,443:
+//
+//	ports, err := env.GetSlice("PORTS", ",", env.ParseInt) // []int{80, 443}
+func GetSlice[T any](key, sep string, parser Parser[T]) ([]T, error) {
+	return GetSliceFrom(defaultEnvironment, key, sep, parser)
+}
+
+// GetOrSlice retrieves an environment variable, splits it on sep and parses each element.
+// If the variable is not set or any element fails to parse, it returns the default value.
+func GetOrSlice[T any](key, sep string, defaultValue []T, parser Parser[T]) []T {
+	return GetOrSliceFrom(defaultEnvironment, key, sep, defaultValue, parser)
+}
+
+// MustGetSlice retrieves an environment variable, splits it on sep and parses each element.
+// If the variable is not set or any element fails to parse, it panics with a message
+// naming the element. The typeName parameter names the element type.
+func MustGetSlice[T any](key, sep string, parser Parser[T], typeName string) []T {
+	return MustGetSliceFrom(defaultEnvironment, key, sep, parser, typeName)
+}
+
+// GetMap retrieves an environment variable holding key/value pairs such as "a:1,b:2" and
+// parses it into a map. See MapParser for the separators.
+func GetMap[K comparable, V any](key, sep, kvSep string, keyParser Parser[K], valueParser Parser[V]) (map[K]V, error) {
+	return GetMapFrom(defaultEnvironment, key, sep, kvSep, keyParser, valueParser)
+}
+
 // Set sets an environment variable to the specified value.
 // It returns an error if the operation fails.
 //
-// This is a simple wrapper around os.Setenv for consistency with other functions in this package.
+// It changes the process environment, through DefaultEnvironment.
 func Set(key, value string) error {
-	return os.Setenv(key, value)
+	return defaultEnvironment.Set(key, value)
 }
 
 // Unset removes an environment variable.
 // It returns an error if the operation fails.
 //
-// This is a simple wrapper around os.Unsetenv for consistency with other functions in this package.
+// It changes the process environment, through DefaultEnvironment.
 func Unset(key string) error {
-	return os.Unsetenv(key)
+	return defaultEnvironment.Unset(key)
 }
 
 // Get retrieves the value of an environment variable.
 // If the variable is not set, it returns an empty string.
 //
-// This is a simple wrapper around os.Getenv for consistency with other functions in this package.
+// It reads the process environment, through DefaultEnvironment. Use an
+// Environment to read from other sources.
 func Get(key string) string {
-	return os.Getenv(key)
+	return defaultEnvironment.Get(key)
 }
 
 // GetOr retrieves the value of an environment variable.
@@ -134,11 +273,7 @@ func Get(key string) string {
 //
 // This function distinguishes between unset variables and variables set to empty strings.
 func GetOr(key, defaultValue string) string {
-	value, ok := os.LookupEnv(key)
-	if ok {
-		return value
-	}
-	return defaultValue
+	return defaultEnvironment.GetOr(key, defaultValue)
 }
 
 // MustGet retrieves the value of an environment variable.
@@ -146,11 +281,7 @@ func GetOr(key, defaultValue string) string {
 //
 // Use this function when the environment variable is required for the application to function.
 func MustGet(key string) string {
-	value, ok := os.LookupEnv(key)
-	if ok {
-		return value
-	}
-	panic(fmt.Sprintf("expected environment variable \"%s\" does not exist", key))
+	return defaultEnvironment.MustGet(key)
 }
 
 // MustGetWithContext retrieves the value of an environment variable with additional context.
@@ -162,15 +293,12 @@ func MustGet(key string) string {
 // Use this function when the environment variable is required and you want to provide
 // additional context in error messages.
 func MustGetWithContext(key, context string) string {
-	value, ok := os.LookupEnv(key)
-	if ok {
-		return value
-	}
-	panic(fmt.Sprintf("required env var %s missing (needed for: %s)", key, context))
+	return defaultEnvironment.MustGetWithContext(key, context)
 }
 
 // GetBool retrieves an environment variable and parses it as a boolean.
-// It accepts values like "true", "false", "1", "0", "t", "f", "T", "F", "TRUE", "FALSE" (case-insensitive).
+// It accepts values like "true", "false", "1", "0", "yes", "no", "on" and "off" (case-insensitive);
+// see SetBoolVocabulary to change the accepted words.
 // Returns the parsed boolean value and any parsing error.
 func GetBool(key string) (bool, error) {
 	return GetParsed(key, ParseBool)
@@ -317,18 +445,7 @@ func GetDuration(key string) (time.Duration, error) {
 // Note: This function takes a string default value (unlike other GetOr* functions)
 // to maintain backwards compatibility with existing APIs.
 func GetOrDuration(key string, defaultValue string) time.Duration {
-	strValue, ok := os.LookupEnv(key)
-	if ok {
-		value, err := time.ParseDuration(strValue)
-		if err == nil {
-			return value
-		}
-	}
-	defaultDuration, err := time.ParseDuration(defaultValue)
-	if err != nil {
-		panic(fmt.Sprintf("default duration \"%s\" could not be converted to time.Duration", defaultValue))
-	}
-	return defaultDuration
+	return defaultEnvironment.GetOrDuration(key, defaultValue)
 }
 
 // MustGetDuration retrieves an environment variable and parses it as a time.Duration.
@@ -351,18 +468,7 @@ func GetUrl(key string) (*url.URL, error) {
 // Note: This function takes a string default value (unlike other GetOr* functions)
 // to maintain backwards compatibility with existing APIs.
 func GetOrUrl(key string, defaultValue string) *url.URL {
-	strValue, ok := os.LookupEnv(key)
-	if ok {
-		value, err := url.ParseRequestURI(strValue)
-		if err == nil {
-			return value
-		}
-	}
-	defaultUrl, err := url.ParseRequestURI(defaultValue)
-	if err != nil {
-		panic(fmt.Sprintf("default url \"%s\" could not be converted to url.URL", defaultValue))
-	}
-	return defaultUrl
+	return defaultEnvironment.GetOrUrl(key, defaultValue)
 }
 
 // MustGetUrl retrieves an environment variable and parses it as a URL.
@@ -371,6 +477,30 @@ func MustGetUrl(key string) *url.URL {
 	return MustGetParsed(key, ParseURL, "url.URL")
 }
 
+// GetStrings retrieves an environment variable and splits it on sep, which defaults to ",".
+// An empty variable is an empty slice; an unset one is an error wrapping ErrNotSet.
+func GetStrings(key, sep string) ([]string, error) {
+	return defaultEnvironment.GetStrings(key, sep)
+}
+
+// GetInts retrieves an environment variable, splits it on sep and parses each element as an int.
+// Errors name the element that failed.
+func GetInts(key, sep string) ([]int, error) {
+	return defaultEnvironment.GetInts(key, sep)
+}
+
+// GetDurations retrieves an environment variable, splits it on sep and parses each element
+// as a time.Duration. Errors name the element that failed.
+func GetDurations(key, sep string) ([]time.Duration, error) {
+	return defaultEnvironment.GetDurations(key, sep)
+}
+
+// GetURLs retrieves an environment variable, splits it on sep and parses each element as a URL.
+// Errors name the element that failed.
+func GetURLs(key, sep string) ([]*url.URL, error) {
+	return defaultEnvironment.GetURLs(key, sep)
+}
+
 // TestHelper is an interface that represents a testing object (typically *testing.T or *testing.B).
 // It provides the minimal interface needed for test helper functions.
 type TestHelper interface {
@@ -1,8 +1,11 @@
 package env
 
 import (
+	"errors"
+	"fmt"
 	"net/url"
 	"os"
+	"strconv"
 	"testing"
 	"time"
 
@@ -270,6 +273,132 @@ func TestUrlFuncs(t *testing.T) {
 	assert.Panics(t, func() { MustGetUrl("BAD_URL") }, "The code did not panic")
 }
 
+func TestSliceFuncs(t *testing.T) {
+	SetForTest(t, "SLICE_INTS", "1,2,3")
+	SetForTest(t, "SLICE_BAD", "1,x,3")
+	SetForTest(t, "SLICE_SEMI", "a;b;c")
+	SetForTest(t, "SLICE_EMPTY", "")
+	UnsetForTest(t, "SLICE_MISSING")
+
+	// env exists
+	ints, err := GetSlice("SLICE_INTS", ",", ParseInt)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, ints)
+	assert.Equal(t, []int{1, 2, 3}, MustGetSlice("SLICE_INTS", "", ParseInt, "int"))
+	assert.Equal(t, []int{1, 2, 3}, GetOrSlice("SLICE_INTS", ",", []int{9}, ParseInt))
+	strs, err := GetStrings("SLICE_SEMI", ";")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, strs)
+
+	// env empty or not exists
+	ints, err = GetSlice("SLICE_EMPTY", ",", ParseInt)
+	assert.NoError(t, err)
+	assert.Empty(t, ints)
+	_, err = GetStrings("SLICE_MISSING", ",")
+	assert.ErrorIs(t, err, ErrNotSet)
+	assert.Equal(t, []int{9}, GetOrSlice("SLICE_MISSING", ",", []int{9}, ParseInt))
+	assert.PanicsWithValue(t, `expected environment variable "SLICE_MISSING" does not exist`, func() {
+		MustGetSlice("SLICE_MISSING", ",", ParseInt, "int")
+	})
+
+	// env bad format names the element
+	_, err = GetInts("SLICE_BAD", ",")
+	assert.EqualError(t, err, `environment variable "SLICE_BAD" has an invalid []int value "1,x,3": element 1 ("x"): strconv.ParseInt: parsing "x": invalid syntax`)
+	assert.Equal(t, []int{9}, GetOrSlice("SLICE_BAD", ",", []int{9}, ParseInt))
+	assert.PanicsWithValue(t, `environment variable "SLICE_BAD" could not be converted to []int: element 1 ("x"): strconv.ParseInt: parsing "x": invalid syntax`, func() {
+		MustGetSlice("SLICE_BAD", ",", ParseInt, "int")
+	})
+}
+
+func TestTypedSliceFuncs(t *testing.T) {
+	SetForTest(t, "SLICE_DURATIONS", "1s|2m")
+	SetForTest(t, "SLICE_URLS", "https://a.example.com,https://b.example.com")
+	SetForTest(t, "SLICE_BAD_URLS", "https://a.example.com,not a url")
+
+	durations, err := GetDurations("SLICE_DURATIONS", "|")
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Minute}, durations)
+
+	urls, err := GetURLs("SLICE_URLS", ",")
+	assert.NoError(t, err)
+	if assert.Len(t, urls, 2) {
+		assert.Equal(t, "b.example.com", urls[1].Host)
+	}
+
+	_, err = GetURLs("SLICE_BAD_URLS", ",")
+	assert.ErrorContains(t, err, `element 1 ("not a url")`)
+}
+
+func TestMapFuncs(t *testing.T) {
+	SetForTest(t, "MAP_LIMITS", "read:10,write:2")
+	SetForTest(t, "MAP_EQUALS", "a=1;b=2")
+	SetForTest(t, "MAP_NO_SEP", "read:10,write")
+	SetForTest(t, "MAP_BAD_VALUE", "read:10,write:x")
+
+	limits, err := GetMap("MAP_LIMITS", "", "", ParseString, ParseInt)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"read": 10, "write": 2}, limits)
+
+	equals, err := GetMap("MAP_EQUALS", ";", "=", ParseString, ParseString)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": "1", "b": "2"}, equals)
+
+	_, err = GetMap("MAP_NO_SEP", ",", ":", ParseString, ParseInt)
+	assert.ErrorContains(t, err, `element 1 ("write"): missing ":" between key and value`)
+
+	_, err = GetMap("MAP_BAD_VALUE", ",", ":", ParseString, ParseInt)
+	assert.ErrorContains(t, err, `element 1 ("write:x"): value: `)
+}
+
+func TestGetErrors(t *testing.T) {
+	SetForTest(t, "ERR_PORT", "80a")
+	SetForTest(t, "ERR_API_TOKEN", "s3cr3t")
+	UnsetForTest(t, "ERR_MISSING")
+
+	_, err := GetInt("ERR_MISSING")
+	assert.ErrorIs(t, err, ErrNotSet)
+	assert.EqualError(t, err, "environment variable not set: ERR_MISSING")
+
+	_, err = GetInt("ERR_PORT")
+	var valueErr *ValueError
+	if assert.ErrorAs(t, err, &valueErr) {
+		assert.Equal(t, "ERR_PORT", valueErr.Key)
+		assert.Equal(t, "80a", valueErr.Value)
+		assert.Equal(t, "int", valueErr.Type)
+		assert.ErrorIs(t, err, strconv.ErrSyntax)
+	}
+	assert.False(t, errors.Is(err, ErrNotSet))
+	assert.EqualError(t, err, `environment variable "ERR_PORT" has an invalid int value "80a": strconv.ParseInt: parsing "80a": invalid syntax`)
+
+	_, err = GetDuration("ERR_API_TOKEN")
+	assert.EqualError(t, err, `environment variable "ERR_API_TOKEN" has an invalid time.Duration value "****"`)
+}
+
+func TestGetOrReportsInvalidValues(t *testing.T) {
+	SetForTest(t, "ERR_PORT", "80a")
+	SetForTest(t, "ERR_TIMEOUT", "soon")
+	UnsetForTest(t, "ERR_MISSING")
+
+	var reported []string
+	OnInvalidValue = func(err *ValueError) { reported = append(reported, err.Key) }
+	t.Cleanup(func() { OnInvalidValue = nil })
+
+	assert.Equal(t, 8080, GetOrInt("ERR_PORT", 8080))
+	assert.Equal(t, 8080, GetOrInt("ERR_MISSING", 8080))
+	assert.Equal(t, time.Second, GetOrDuration("ERR_TIMEOUT", "1s"))
+	assert.Equal(t, []string{"ERR_PORT", "ERR_TIMEOUT"}, reported)
+
+	OnInvalidValue = nil
+	var logged []string
+	EnableDebugLogging(func(format string, args ...interface{}) {
+		logged = append(logged, fmt.Sprintf(format, args...))
+	})
+	t.Cleanup(func() { EnableDebugLogging(nil) })
+
+	assert.Equal(t, 8080, GetOrInt("ERR_PORT", 8080))
+	assert.Equal(t, []string{`env: environment variable "ERR_PORT" has an invalid int value "80a": strconv.ParseInt: parsing "80a": invalid syntax; using the default`}, logged)
+}
+
 // Test environment variable key validation
 func TestEnvVarKeyValidation(t *testing.T) {
 	// Valid keys